@@ -0,0 +1,183 @@
+// Package client is a Go SDK for the shortener's HTTP API.
+//
+// Consumers should depend on the Shortener interface, so that their
+// tests can use Fake instead of a running server:
+//
+//	c := client.New("https://miniurl.example", client.WithAPIKey(key))
+//	link, err := c.Shorten(ctx, client.ShortenRequest{URL: "https://example.com"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shortener is implemented by Client and by Fake
+type Shortener interface {
+	// Shorten creates a short link, or returns the existing one for
+	// a plain URL that was shortened before
+	Shorten(ctx context.Context, req ShortenRequest) (*ShortenResponse, error)
+
+	// ShortenBulk shortens many URLs at once (API key required).
+	// Invalid requests are reported per row and don't fail the call.
+	ShortenBulk(ctx context.Context, reqs []ShortenRequest) (*BulkResponse, error)
+
+	// Resolve returns where the link with the given code leads
+	Resolve(ctx context.Context, code string) (*LinkInfo, error)
+}
+
+// Client talks to a shortener server over HTTP. It is safe for
+// concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+
+	// Rate-limited (429) requests are retried up to maxRetries times,
+	// waiting as long as the server asks but at most maxBackoff
+	maxRetries int
+	maxBackoff time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with the given API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient sends requests through hc instead of a default client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how often rate-limited requests are retried
+// (3 by default) and the longest wait between attempts (30s)
+func WithRetries(maxRetries int, maxBackoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.maxBackoff = maxRetries, maxBackoff }
+}
+
+// New returns a client for the server at baseURL, e.g. "https://miniurl.example"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		maxBackoff: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+var _ Shortener = (*Client)(nil)
+
+func (c *Client) Shorten(ctx context.Context, req ShortenRequest) (*ShortenResponse, error) {
+	body := []byte(req.values().Encode())
+
+	var res ShortenResponse
+	if err := c.do(ctx, http.MethodPost, "/shorten-url", "application/x-www-form-urlencoded", body, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ShortenBulk(ctx context.Context, reqs []ShortenRequest) (*BulkResponse, error) {
+	rows := make([]map[string]string, len(reqs))
+	for i, req := range reqs {
+		rows[i] = make(map[string]string)
+		for field, values := range req.values() {
+			rows[i][field] = values[0]
+		}
+	}
+	body, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var res BulkResponse
+	if err := c.do(ctx, http.MethodPost, "/shorten-bulk", "application/json", body, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) Resolve(ctx context.Context, code string) (*LinkInfo, error) {
+	var res LinkInfo
+	if err := c.do(ctx, http.MethodGet, "/"+url.PathEscape(code)+"/info", "", nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+/**** Helper Methods below ****/
+
+// do sends the request, retrying while rate-limited, and decodes
+// the JSON response into out
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if c.apiKey != "" {
+			req.Header.Set("X-API-Key", c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < c.maxRetries {
+			wait := c.retryDelay(resp, attempt)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// retryDelay is how long to wait before retrying a rate-limited request:
+// what the server asks for in Retry-After or X-RateLimit-Reset, falling
+// back to exponential backoff with jitter
+func (c *Client) retryDelay(resp *http.Response, attempt int) time.Duration {
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		wait = time.Until(time.Unix(reset, 0))
+	} else {
+		wait = 500 * time.Millisecond << attempt
+		wait += rand.N(wait / 2)
+	}
+
+	return max(0, min(wait, c.maxBackoff))
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rateLimited answers the first `limited` requests with a 429 carrying
+// the given headers, and later ones with a shortened link
+func rateLimited(t *testing.T, limited int32, headers func() http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= limited {
+			for key, values := range headers() {
+				w.Header()[key] = values
+			}
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"code":"6LAzd","short_url":"http://miniurl.test/6LAzd"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, hits := rateLimited(t, 1, func() http.Header {
+		return http.Header{"Retry-After": {"1"}}
+	})
+
+	start := time.Now()
+	res, err := New(srv.URL).Shorten(context.Background(), ShortenRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "6LAzd" || hits.Load() != 2 {
+		t.Errorf("got %+v after %d requests, want 6LAzd after 2", res, hits.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the 1s asked for", elapsed)
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	srv, hits := rateLimited(t, 1, func() http.Header {
+		reset := time.Now().Add(time.Second).Unix()
+		return http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}
+	})
+
+	res, err := New(srv.URL).Shorten(context.Background(), ShortenRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "6LAzd" || hits.Load() != 2 {
+		t.Errorf("got %+v after %d requests, want 6LAzd after 2", res, hits.Load())
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := New("http://miniurl.test", WithRetries(3, 30*time.Second))
+	inSeconds := func(d time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(d).Unix(), 10)
+	}
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		attempt  int
+		min, max time.Duration
+	}{
+		{"retry after", http.Header{"Retry-After": {"7"}}, 0, 7 * time.Second, 7 * time.Second},
+		{"retry after wins", http.Header{"Retry-After": {"2"}, "X-Ratelimit-Reset": {inSeconds(time.Minute)}}, 0, 2 * time.Second, 2 * time.Second},
+		{"reset", http.Header{"X-Ratelimit-Reset": {inSeconds(10 * time.Second)}}, 0, 9 * time.Second, 10 * time.Second},
+		{"reset passed", http.Header{"X-Ratelimit-Reset": {inSeconds(-time.Minute)}}, 0, 0, 0},
+		{"capped", http.Header{"Retry-After": {"3600"}}, 0, 30 * time.Second, 30 * time.Second},
+		{"backoff", http.Header{}, 0, 500 * time.Millisecond, 750 * time.Millisecond},
+		{"backoff doubles", http.Header{}, 2, 2 * time.Second, 3 * time.Second},
+		{"backoff capped", http.Header{}, 10, 30 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait := c.retryDelay(&http.Response{Header: tt.header}, tt.attempt)
+			if wait < tt.min || wait > tt.max {
+				t.Errorf("retryDelay = %v, want between %v and %v", wait, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	srv, hits := rateLimited(t, 100, func() http.Header {
+		return http.Header{"Retry-After": {"1"}}
+	})
+
+	c := New(srv.URL, WithRetries(2, time.Millisecond))
+	_, err := c.Shorten(context.Background(), ShortenRequest{URL: "https://example.com"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("err = %v, want the last 429", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("sent %d requests, want 1 plus 2 retries", hits.Load())
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	srv, hits := rateLimited(t, 100, func() http.Header {
+		return http.Header{"Retry-After": {"30"}}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := New(srv.URL).Shorten(ctx, ShortenRequest{URL: "https://example.com"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want as soon as the context ends", elapsed)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("sent %d requests, want 1", hits.Load())
+	}
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/internal/utils"
+)
+
+// Fake is an in-memory Shortener for the tests of code using the client.
+// Like a server with default settings, it validates URLs and reuses the
+// link of a plain URL that was shortened before, comparing URLs in their
+// normalised form. The zero value is ready to use.
+type Fake struct {
+	// BaseURL prefixes short URLs, "https://miniurl.test" if empty
+	BaseURL string
+
+	mu    sync.Mutex
+	links map[string]*fakeLink // by code
+	plain map[string]string    // dedup key -> code of its plain link
+}
+
+type fakeLink struct {
+	req       ShortenRequest
+	createdAt time.Time
+	clicks    int64
+}
+
+// NewFake returns an empty Fake
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+var _ Shortener = (*Fake)(nil)
+
+func (f *Fake) Shorten(ctx context.Context, req ShortenRequest) (*ShortenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res, _, err := f.shorten(req)
+	return res, err
+}
+
+func (f *Fake) ShortenBulk(ctx context.Context, reqs []ShortenRequest) (*BulkResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &BulkResponse{Results: make([]BulkResult, len(reqs))}
+	// Code -> row that created it, for requests repeating a plain URL
+	createdBy := make(map[string]int)
+	for i, req := range reqs {
+		result := &res.Results[i]
+		result.Row, result.URL = i+1, req.URL
+
+		link, existing, err := f.shorten(req)
+		switch {
+		case err != nil:
+			result.Error = err.(*APIError).Message
+			res.Failed++
+		case existing && createdBy[link.Code] > 0:
+			result.Code, result.ShortURL = link.Code, link.ShortURL
+			result.DuplicateOf = createdBy[link.Code]
+			res.Duplicates++
+		case existing:
+			result.Code, result.ShortURL, result.Existing = link.Code, link.ShortURL, true
+			res.Existing++
+		default:
+			result.Code, result.ShortURL = link.Code, link.ShortURL
+			createdBy[link.Code] = result.Row
+			res.Created++
+		}
+	}
+	return res, nil
+}
+
+func (f *Fake) Resolve(ctx context.Context, code string) (*LinkInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.links[code]
+	if !ok {
+		return nil, &APIError{StatusCode: 404, Message: "Link not found!"}
+	}
+	info := &LinkInfo{
+		Code:        code,
+		ShortURL:    f.shortURL(code),
+		Protected:   link.req.Password != "",
+		CreatedAt:   link.createdAt,
+		TotalClicks: link.clicks,
+	}
+	if !info.Protected {
+		info.LongURL = link.req.URL
+		if u, err := url.Parse(link.req.URL); err == nil {
+			info.Domain = u.Hostname()
+		}
+	}
+	return info, nil
+}
+
+// Click records a visit of the link, as reported by Resolve
+func (f *Fake) Click(code string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if link, ok := f.links[code]; ok {
+		link.clicks++
+	}
+}
+
+// Request returns the request the link with the given code was created with
+func (f *Fake) Request(code string) (ShortenRequest, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.links[code]
+	if !ok {
+		return ShortenRequest{}, false
+	}
+	return link.req, true
+}
+
+/**** Helper Methods below ****/
+
+func (f *Fake) shorten(req ShortenRequest) (*ShortenResponse, bool, error) {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false, &APIError{StatusCode: 400, Message: "Invalid or unsafe URL"}
+	}
+	dedupKey, err := utils.NormalizeURL(u.String(), utils.NormalizeOptions{})
+	if err != nil {
+		return nil, false, &APIError{StatusCode: 400, Message: "Invalid or unsafe URL"}
+	}
+	switch req.RedirectType {
+	case 0, 301, 302, 307, 308:
+	default:
+		return nil, false, &APIError{StatusCode: 400, Message: "Invalid redirect type: must be one of 301, 302, 307, 308"}
+	}
+	if req.MaxClicks < 0 {
+		return nil, false, &APIError{StatusCode: 400, Message: "Max clicks must be a positive number"}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.links == nil {
+		f.links = make(map[string]*fakeLink)
+		f.plain = make(map[string]string)
+	}
+
+	isPlain := req.values().Encode() == url.Values{"url": {req.URL}}.Encode()
+	if code, ok := f.plain[dedupKey]; ok && isPlain {
+		return &ShortenResponse{Code: code, ShortURL: f.shortURL(code)}, true, nil
+	}
+
+	code := fmt.Sprintf("fake%d", len(f.links)+1)
+	f.links[code] = &fakeLink{req: req, createdAt: time.Now().UTC()}
+	if isPlain {
+		f.plain[dedupKey] = code
+	}
+	return &ShortenResponse{Code: code, ShortURL: f.shortURL(code)}, false, nil
+}
+
+func (f *Fake) shortURL(code string) string {
+	base := f.BaseURL
+	if base == "" {
+		base = "https://miniurl.test"
+	}
+	return strings.TrimSuffix(base, "/") + "/" + code
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFakeReusesPlainLinks(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+
+	first, err := f.Shorten(ctx, ShortenRequest{URL: "https://example.com/page?a=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same URL in another form, as the server normalises it
+	for _, rawURL := range []string{
+		"https://example.com/page?a=1",
+		"HTTPS://Example.COM/page?a=1",
+		"https://example.com:443/page?a=1",
+		"https://example.com./%70age?a=1",
+		" https://example.com/page?a=1 ",
+	} {
+		res, err := f.Shorten(ctx, ShortenRequest{URL: rawURL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Code != first.Code {
+			t.Errorf("%q got %s, want the existing %s", rawURL, res.Code, first.Code)
+		}
+	}
+
+	// Other URLs and links with options get their own code
+	for _, req := range []ShortenRequest{
+		{URL: "https://example.com/page?a=2"},
+		{URL: "https://example.com/page?a=1#section"},
+		{URL: "https://example.com/Page?a=1"},
+		{URL: "https://example.com/page?a=1", MaxClicks: 1},
+	} {
+		res, err := f.Shorten(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Code == first.Code {
+			t.Errorf("%+v reused %s", req, first.Code)
+		}
+	}
+
+	bulk, err := f.ShortenBulk(ctx, []ShortenRequest{
+		{URL: "https://EXAMPLE.com/page?a=1"},
+		{URL: "ftp://example.com/"},
+		{URL: "https://example.com/new"},
+		{URL: "https://example.com/new"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bulk.Existing != 1 || bulk.Failed != 1 || bulk.Created != 1 || bulk.Duplicates != 1 ||
+		bulk.Results[0].Code != first.Code {
+		t.Errorf("ShortenBulk = %+v, want the existing link, a failure, a new link and its duplicate", bulk)
+	}
+	if repeat := bulk.Results[3]; repeat.DuplicateOf != 3 || repeat.Existing || repeat.Code != bulk.Results[2].Code {
+		t.Errorf("repeated request = %+v, want a duplicate of row 3", repeat)
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is matched (with errors.Is) by errors about unknown links
+var ErrNotFound = errors.New("link not found")
+
+// APIError is a non-2xx response from the shortener
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("miniurl: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == 404
+}
+
+// ShortenRequest describes a link to create. Only URL is required;
+// zero values leave an option unset.
+type ShortenRequest struct {
+	URL string
+
+	// RedirectType is 301, 302, 307 or 308; the server's default if zero
+	RedirectType int
+
+	// MaxClicks expires the link after this many clicks
+	MaxClicks int64
+
+	// NotBefore and NotAfter bound when the link can be followed.
+	// FallbackURL is used outside of that window.
+	NotBefore   time.Time
+	NotAfter    time.Time
+	FallbackURL string
+
+	// Targets maps platforms (ios, android, windows, macos, linux,
+	// mobile, desktop) to their destination
+	Targets map[string]string
+
+	// GeoRules route visitors by country, in order
+	GeoRules []GeoRule
+
+	// Variants split visitors across weighted destinations
+	Variants []Variant
+
+	// PassQuery and PassPath forward the visitor's query string
+	// and extra path to the destination
+	PassQuery bool
+	PassPath  bool
+
+	// Password protects the link
+	Password string
+
+	// Interstitial is "always", "never" (trusted API keys only)
+	// or empty for the server-wide setting
+	Interstitial string
+
+	Campaign Campaign
+}
+
+// GeoRule sends visitors from Country (ISO code, e.g. "DE") to URL
+type GeoRule struct {
+	Country string
+	URL     string
+}
+
+// Variant is one weighted destination of an A/B split link
+type Variant struct {
+	Weight int
+	URL    string
+}
+
+// Campaign holds the UTM params added to the destination
+type Campaign struct {
+	Source  string
+	Medium  string
+	Name    string
+	Term    string
+	Content string
+}
+
+// ShortenResponse is a created (or reused) short link
+type ShortenResponse struct {
+	Code     string `json:"code"`
+	ShortURL string `json:"short_url"`
+}
+
+// BulkResponse is the outcome of a bulk shorten
+type BulkResponse struct {
+	Created    int          `json:"created"`
+	Existing   int          `json:"existing"`
+	Duplicates int          `json:"duplicates"`
+	Failed     int          `json:"failed"`
+	Results    []BulkResult `json:"results"`
+}
+
+// BulkResult is the outcome of one request of a bulk shorten.
+// Row is the 1-based index of the request, and DuplicateOf the Row
+// of an earlier request for the same plain link, whose link it shares.
+type BulkResult struct {
+	Row         int    `json:"row"`
+	URL         string `json:"url,omitempty"`
+	Code        string `json:"code,omitempty"`
+	ShortURL    string `json:"short_url,omitempty"`
+	Existing    bool   `json:"existing,omitempty"`
+	DuplicateOf int    `json:"duplicate_of,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// LinkInfo is where a short link leads, without following it
+type LinkInfo struct {
+	Code     string `json:"code"`
+	ShortURL string `json:"short_url"`
+
+	// Destination, hidden for password-protected links
+	Domain    string `json:"domain,omitempty"`
+	LongURL   string `json:"long_url,omitempty"`
+	Protected bool   `json:"protected"`
+
+	Metadata    *Metadata `json:"metadata,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	TotalClicks int64     `json:"total_clicks"`
+}
+
+// Metadata describes the destination, once the server has fetched it
+type Metadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	StatusCode  int    `json:"status_code"`
+	FinalURL    string `json:"final_url,omitempty"`
+}
+
+/**** Helper Methods below ****/
+
+// values encodes the request as the shorten form's fields
+func (r ShortenRequest) values() url.Values {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+
+	set("url", r.URL)
+	if r.RedirectType != 0 {
+		set("redirect_type", strconv.Itoa(r.RedirectType))
+	}
+	if r.MaxClicks != 0 {
+		set("max_clicks", strconv.FormatInt(r.MaxClicks, 10))
+	}
+	if !r.NotBefore.IsZero() {
+		set("not_before", r.NotBefore.Format(time.RFC3339))
+	}
+	if !r.NotAfter.IsZero() {
+		set("not_after", r.NotAfter.Format(time.RFC3339))
+	}
+	set("fallback_url", r.FallbackURL)
+
+	for platform, destination := range r.Targets {
+		set("target_"+platform, destination)
+	}
+	var lines []string
+	for _, rule := range r.GeoRules {
+		lines = append(lines, rule.Country+" "+rule.URL)
+	}
+	set("geo_rules", strings.Join(lines, "\n"))
+
+	lines = nil
+	for _, variant := range r.Variants {
+		lines = append(lines, strconv.Itoa(variant.Weight)+" "+variant.URL)
+	}
+	set("variants", strings.Join(lines, "\n"))
+
+	if r.PassQuery {
+		set("pass_query", "1")
+	}
+	if r.PassPath {
+		set("pass_path", "1")
+	}
+	set("password", r.Password)
+	set("interstitial", r.Interstitial)
+
+	set("utm_source", r.Campaign.Source)
+	set("utm_medium", r.Campaign.Medium)
+	set("utm_campaign", r.Campaign.Name)
+	set("utm_term", r.Campaign.Term)
+	set("utm_content", r.Campaign.Content)
+	return v
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+)
+
+// cachePatterns match the Redis keys that only cache SQLite data.
+// Click budgets and rate limits are state, and are never flushed.
+var cachePatterns = []string{"code_to_link:*", "long_to_id:*", "qr:*"}
+
+// rebuildBloom asks every running server to rebuild its Bloom filter,
+// which lives in the server's memory
+func rebuildBloom(a *app, args []string) error {
+	servers, err := analytics.SendAdminEvent(a.ctx, a.rdb, events.AdminEvent{Type: events.BloomRebuild})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Asked %d server(s) to rebuild their Bloom filter\n", servers)
+	return nil
+}
+
+// reloadBots asks every running server to read its bot
+// user-agent patterns again, e.g. after the file was updated
+func reloadBots(a *app, args []string) error {
+	servers, err := analytics.SendAdminEvent(a.ctx, a.rdb, events.AdminEvent{Type: events.BotsReload})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Asked %d server(s) to reload their bot patterns\n", servers)
+	return nil
+}
+
+// flushCache drops every cached link, dedup mapping and QR code
+func flushCache(a *app, args []string) error {
+	var deleted int
+	for _, pattern := range cachePatterns {
+		iter := a.rdb.Scan(a.ctx, 0, pattern, 500).Iterator()
+		var batch []string
+		for iter.Next(a.ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == 500 {
+				if err := a.rdb.Del(a.ctx, batch...).Err(); err != nil {
+					return err
+				}
+				deleted += len(batch)
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := a.rdb.Del(a.ctx, batch...).Err(); err != nil {
+				return err
+			}
+			deleted += len(batch)
+		}
+	}
+	fmt.Println("Deleted", deleted, "cached key(s)")
+	return nil
+}
+
+// warmCache caches the most clicked links, e.g. after a flush
+// or before a launch
+func warmCache(a *app, args []string) error {
+	fs := flag.NewFlagSet("warm-cache", flag.ExitOnError)
+	n := fs.Int("n", 1000, "number of links")
+	fs.Parse(args)
+
+	top, err := links.Top(a.ctx, a.db, *n)
+	if err != nil {
+		return err
+	}
+	for _, t := range top {
+		link, err := links.Get(a.ctx, a.db, t.ID)
+		if err != nil {
+			return err
+		}
+		links.Cache(a.ctx, a.rdb, links.CodeOf(a.ctx, a.db, link.ID), link)
+
+		dedupKey, err := links.DedupKey(a.ctx, a.db, link.ID)
+		if err != nil {
+			return err
+		}
+		if dedupKey != "" {
+			links.CacheDedup(a.ctx, a.rdb, link, dedupKey)
+		}
+	}
+	fmt.Println("Cached", len(top), "link(s)")
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"url-shortener/internal/db"
+)
+
+// migrate brings the schema up to date, like the server does on startup
+func migrate(a *app, args []string) error {
+	sqlite, err := db.Open(a.cfg.SQLitePath)
+	if err != nil {
+		return err
+	}
+	defer sqlite.Close()
+
+	before, err := db.Version(sqlite)
+	if err != nil {
+		return err
+	}
+	if err := db.Migrate(sqlite); err != nil {
+		return err
+	}
+	fmt.Printf("Schema migrated from version %d to %d\n", before, db.LatestVersion())
+	return nil
+}
+
+// backup writes a consistent copy of the database to path while the
+// server keeps running. The schema isn't migrated first, so backups
+// can be taken before upgrading.
+func backup(a *app, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: miniurlctl backup <path>")
+	}
+	path := args[0]
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	sqlite, err := db.Open(a.cfg.SQLitePath)
+	if err != nil {
+		return err
+	}
+	defer sqlite.Close()
+
+	if _, err := sqlite.ExecContext(a.ctx, "VACUUM INTO ?", path); err != nil {
+		return err
+	}
+	fmt.Println("Backed up", a.cfg.SQLitePath, "to", path)
+	return nil
+}
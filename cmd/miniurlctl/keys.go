@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"url-shortener/internal/auth"
+)
+
+// apiKey manages the API keys used by trusted and bulk clients
+func apiKey(a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: miniurlctl apikey create|list|revoke ...")
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ExitOnError)
+		name := fs.String("name", "", "who the key is for")
+		trusted := fs.Bool("trusted", false, "links skip the safety interstitial")
+		bulkRowLimit := fs.Int("bulk-row-limit", 0, "max rows per bulk upload, server default if 0")
+		fs.Parse(args[1:])
+
+		key, err := auth.CreateKey(a.ctx, a.db, *name, *trusted, *bulkRowLimit)
+		if err != nil {
+			return err
+		}
+		// Only hashes are stored, so this is the only time it's shown
+		fmt.Println(key)
+		return nil
+
+	case "list":
+		keys, err := auth.ListKeys(a.ctx, a.db)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tTRUSTED\tBULK ROW LIMIT")
+		for _, key := range keys {
+			limit := "default"
+			if key.BulkRowLimit > 0 {
+				limit = strconv.Itoa(key.BulkRowLimit)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%t\t%s\n", key.ID, key.Name, key.Trusted, limit)
+		}
+		return tw.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New("usage: miniurlctl apikey revoke <id>")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errors.New("invalid key id")
+		}
+		if err := auth.RevokeKey(a.ctx, a.db, id); err != nil {
+			return err
+		}
+		fmt.Println("Revoked key", id)
+		return nil
+	}
+	return fmt.Errorf("unknown apikey command %q", args[0])
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+// createLink creates a link the way ShortenURL does. Operators are
+// trusted, so their links skip the interstitial unless asked otherwise.
+func createLink(a *app, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	redirectType := fs.Int("redirect-type", 0, "redirect status (301, 302, 307 or 308), server default if 0")
+	maxClicks := fs.Int64("max-clicks", 0, "expire the link after this many clicks, 0 for unlimited")
+	interstitial := fs.String("interstitial", links.InterstitialNever, `"always", "never" or "" for the server-wide setting`)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: miniurlctl create [flags] <url>")
+	}
+
+	longURL, err := utils.ValidateLongURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	link := &links.Link{
+		LongURL:      longURL,
+		RedirectType: *redirectType,
+		MaxClicks:    *maxClicks,
+		Interstitial: *interstitial,
+		Suspicious:   links.LooksSuspicious(longURL),
+	}
+	if link.RedirectType != 0 && !links.ValidRedirectType(link.RedirectType) {
+		return errors.New("invalid redirect type: must be one of 301, 302, 307, 308")
+	}
+	if link.MaxClicks < 0 {
+		return errors.New("max clicks must be zero or more")
+	}
+	if link.IsVolatile() && links.IsPermanentRedirect(link.RedirectType) {
+		return errors.New("click-limited links can't use a permanent redirect")
+	}
+	if !links.ValidInterstitial(link.Interstitial) {
+		return errors.New(`invalid interstitial: must be "always", "never" or ""`)
+	}
+
+	dedupKey, err := utils.NormalizeURL(link.LongURL, a.cfg.Normalize)
+	if err != nil {
+		return err
+	}
+	if err := links.Create(a.ctx, a.db, link, dedupKey); err != nil {
+		return err
+	}
+
+	// Servers pick up plain links once their Bloom filter is rebuilt
+	code := utils.Base62Encode(link.ID)
+	links.Cache(a.ctx, a.rdb, code, link)
+	if link.IsPlain() {
+		links.CacheDedup(a.ctx, a.rdb, link, dedupKey)
+	}
+	sendLinkEvent(a, events.LinkCreated, code, link)
+
+	fmt.Println(code)
+	return nil
+}
+
+// lookupLink prints everything stored about a link
+func lookupLink(a *app, args []string) error {
+	code, link, err := linkArg(a, args)
+	if err != nil {
+		return err
+	}
+	clickCount, lastVisited, err := links.ClickStats(a.ctx, a.db, link.ID)
+	if err != nil {
+		return err
+	}
+	botClicks, err := links.BotClicks(a.ctx, a.db, link.ID)
+	if err != nil {
+		return err
+	}
+	visitors, err := links.CountVisitors(a.ctx, a.rdb, link.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	out := struct {
+		Code           string               `json:"code"`
+		Link           *links.Link          `json:"link"`
+		ClickCount     int64                `json:"click_count"`
+		BotClickCount  int64                `json:"bot_click_count"`
+		UniqueVisitors links.UniqueVisitors `json:"unique_visitors"`
+		LastVisitedAt  *time.Time           `json:"last_visited_at"`
+	}{code, link, clickCount, botClicks, visitors, lastVisited}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func disableLink(a *app, args []string) error {
+	return setDisabled(a, args, true)
+}
+
+func enableLink(a *app, args []string) error {
+	return setDisabled(a, args, false)
+}
+
+// deleteLink removes a link for good. Its code becomes free, but
+// issued codes are never handed out again.
+func deleteLink(a *app, args []string) error {
+	code, link, err := linkArg(a, args)
+	if err != nil {
+		return err
+	}
+	dedupKey, err := links.DedupKey(a.ctx, a.db, link.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := links.Delete(a.ctx, a.db, link.ID); err != nil {
+		return err
+	}
+	if err := links.Uncache(a.ctx, a.rdb, code, link, dedupKey); err != nil {
+		return err
+	}
+	a.rdb.Del(a.ctx, links.BudgetKey(link.ID))
+	links.ForgetVisitors(a.ctx, a.rdb, link.ID, time.Now())
+	sendLinkEvent(a, events.LinkDeleted, code, link)
+
+	fmt.Println("Deleted", code)
+	return nil
+}
+
+// topLinks lists the most clicked links
+func topLinks(a *app, args []string) error {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	n := fs.Int("n", 10, "number of links")
+	fs.Parse(args)
+
+	top, err := links.Top(a.ctx, a.db, *n)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CODE\tCLICKS\tURL")
+	for _, link := range top {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", links.CodeOf(a.ctx, a.db, link.ID), link.ClickCount, link.LongURL)
+	}
+	return tw.Flush()
+}
+
+/**** Helper Methods below ****/
+
+// linkArg loads the link whose code is the only argument
+func linkArg(a *app, args []string) (string, *links.Link, error) {
+	if len(args) != 1 {
+		return "", nil, errors.New("expected a single code")
+	}
+	code := args[0]
+
+	id, err := links.LookupCode(a.ctx, a.db, code)
+	if err != nil {
+		return "", nil, notFound(code, err)
+	}
+	link, err := links.Get(a.ctx, a.db, id)
+	if err != nil {
+		return "", nil, notFound(code, err)
+	}
+	return code, link, nil
+}
+
+func setDisabled(a *app, args []string, disabled bool) error {
+	code, link, err := linkArg(a, args)
+	if err != nil {
+		return err
+	}
+	dedupKey, err := links.DedupKey(a.ctx, a.db, link.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := links.SetDisabled(a.ctx, a.db, link.ID, disabled); err != nil {
+		return err
+	}
+	// Servers would keep following the cached link otherwise
+	if err := links.Uncache(a.ctx, a.rdb, code, link, dedupKey); err != nil {
+		return err
+	}
+
+	if disabled {
+		sendLinkEvent(a, events.LinkDisabled, code, link)
+		fmt.Println("Disabled", code)
+	} else {
+		sendLinkEvent(a, events.LinkEnabled, code, link)
+		fmt.Println("Enabled", code)
+	}
+	return nil
+}
+
+func sendLinkEvent(a *app, eventType string, code string, link *links.Link) {
+	event := events.LinkEvent{Type: eventType, ID: link.ID, Code: code, CreatorID: link.CreatorID}
+	if err := analytics.SendLinkEvent(a.ctx, a.rdb, event); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: link event not sent:", err)
+	}
+}
+
+func notFound(code string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("link %q not found", code)
+	}
+	return err
+}
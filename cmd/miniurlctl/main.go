@@ -0,0 +1,85 @@
+// Command miniurlctl operates the shortener directly against the
+// SQLite database and Redis configured for the server (same env vars).
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/db"
+)
+
+// app is what every subcommand runs against
+type app struct {
+	ctx context.Context
+	cfg *config.Config
+	db  *sql.DB
+	rdb *redis.Client
+}
+
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+
+	// raw commands open SQLite themselves, without migrating it first
+	raw bool
+}
+
+var commands = map[string]command{
+	"create":        {usage: "create [flags] <url>", run: createLink},
+	"lookup":        {usage: "lookup <code>", run: lookupLink},
+	"disable":       {usage: "disable <code>", run: disableLink},
+	"enable":        {usage: "enable <code>", run: enableLink},
+	"delete":        {usage: "delete <code>", run: deleteLink},
+	"top":           {usage: "top [-n 10]", run: topLinks},
+	"rebuild-bloom": {usage: "rebuild-bloom", run: rebuildBloom},
+	"reload-bots":   {usage: "reload-bots", run: reloadBots},
+	"flush-cache":   {usage: "flush-cache", run: flushCache},
+	"warm-cache":    {usage: "warm-cache [-n 1000]", run: warmCache},
+	"apikey":        {usage: "apikey create|list|revoke ...", run: apiKey},
+	"migrate":       {usage: "migrate", run: migrate, raw: true},
+	"backup":        {usage: "backup <path>", run: backup, raw: true},
+}
+
+// order in which commands are listed in the usage
+var commandOrder = []string{
+	"create", "lookup", "disable", "enable", "delete", "top",
+	"rebuild-bloom", "reload-bots", "flush-cache", "warm-cache", "apikey", "migrate", "backup",
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	a := &app{ctx: context.Background(), cfg: config.Load()}
+	if !cmd.raw {
+		a.db = db.InitSQLite(a.cfg.SQLitePath)
+		defer a.db.Close()
+	}
+	a.rdb = db.InitRedis()
+	defer a.rdb.Close()
+
+	if err := cmd.run(a, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: miniurlctl <command> [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, name := range commandOrder {
+		fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/bloom"
+	"url-shortener/internal/bots"
+	"url-shortener/internal/events"
+)
+
+// listenAdminEvents applies operators' requests sent with miniurlctl
+func listenAdminEvents(ctx context.Context, rdb *redis.Client, sqlite *sql.DB) {
+	sub := rdb.Subscribe(ctx, analytics.AdminChannel)
+	defer sub.Close()
+
+	for msg := range sub.Channel() {
+		var event events.AdminEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Println("Invalid admin event:", err)
+			continue
+		}
+
+		switch event.Type {
+		case events.BloomRebuild:
+			count, err := bloom.Rebuild(sqlite)
+			if err != nil {
+				log.Println("Bloom rebuild failed: " + err.Error())
+				continue
+			}
+			log.Println("Bloom rebuilt with", count, "keys")
+		case events.BotsReload:
+			count, err := bots.Reload()
+			if err != nil {
+				log.Println("Bot patterns reload failed: " + err.Error())
+				continue
+			}
+			log.Println("Bot patterns reloaded:", count)
+		}
+	}
+}
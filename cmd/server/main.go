@@ -1,24 +1,27 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
-	"os"
 
 	_ "github.com/mattn/go-sqlite3"
 
 	"url-shortener/internal/bloom"
+	"url-shortener/internal/bots"
+	"url-shortener/internal/config"
 	"url-shortener/internal/db"
+	"url-shortener/internal/geoip"
+	"url-shortener/internal/live"
+	"url-shortener/internal/rpc"
 	router "url-shortener/internal/web"
 )
 
 func main() {
-	sqlitePath := os.Getenv("SQLITE_PATH")
-	if sqlitePath == "" {
-		sqlitePath = "./urls.db"
-	}
+	cfg := config.Load()
 
-	sqlite := db.InitSQLite(sqlitePath)
+	sqlite := db.InitSQLite(cfg.SQLitePath)
 	rdb := db.InitRedis()
 
 	bloom.InitBloom(1_000_000, 0.01)
@@ -27,7 +30,36 @@ func main() {
 	}
 	log.Println("Bloom enabled?", bloom.Enabled)
 
-	r := router.New(sqlite, rdb)
+	// e.g. Bloom rebuilds requested with miniurlctl
+	go listenAdminEvents(context.Background(), rdb, sqlite)
+
+	if err := geoip.InitGeoIP(cfg.GeoIPPath); err != nil {
+		log.Println("GeoIP init failed: " + err.Error())
+	}
+	log.Println("GeoIP enabled?", geoip.Enabled)
+
+	patterns, err := bots.InitBots(cfg.BotPatternsPath)
+	if err != nil {
+		log.Fatal("Bot patterns load failed: " + err.Error())
+	}
+	log.Println("Bot user-agent patterns:", patterns)
+
+	// One click subscription shared by every live click stream
+	live.InitLive(rdb, cfg.LiveStreamsPerIP)
+
+	r := router.New(sqlite, rdb, cfg)
+
+	// gRPC API for internal callers, on its own port
+	if cfg.GRPCAddr != "off" {
+		lis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			log.Fatal("gRPC listen failed: " + err.Error())
+		}
+		log.Printf("gRPC server started on %s\n", cfg.GRPCAddr)
+		go func() {
+			log.Fatal(rpc.New(sqlite, rdb, cfg).Serve(lis))
+		}()
+	}
 
 	port := ":8080"
 
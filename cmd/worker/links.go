@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+	"url-shortener/internal/metadata"
+)
+
+// fetchSlots bounds how many destinations are fetched at once
+var fetchSlots = make(chan struct{}, 8)
+
+func handleLinkEvent(dbConn *sql.DB, rdb *redis.Client, fetcher *metadata.Fetcher, payload string) {
+	var event events.LinkEvent
+
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		fmt.Println("Worker: invalid link event:", err)
+		return
+	}
+
+	// Links created with an API key notify its webhooks
+	if event.CreatorID != 0 {
+		queueLinkWebhooks(dbConn, event)
+	}
+
+	switch event.Type {
+	case events.LinkCreated:
+		fetchMetadata(dbConn, rdb, fetcher, event.ID)
+	}
+}
+
+// fetchMetadata retrieves the destination's title, description,
+// OpenGraph image and status code, and stores them with the link.
+// Links that end up on another site are flagged as suspicious.
+func fetchMetadata(dbConn *sql.DB, rdb *redis.Client, fetcher *metadata.Fetcher, id uint64) {
+	fetchSlots <- struct{}{}
+	defer func() { <-fetchSlots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var longURL string
+	if err := dbConn.QueryRowContext(ctx, "SELECT long_url FROM urls WHERE id = ?", id).Scan(&longURL); err != nil {
+		fmt.Println("Worker: metadata lookup error:", err)
+		return
+	}
+
+	meta, fetchErr := fetcher.Fetch(ctx, longURL)
+	if fetchErr != nil {
+		fmt.Println("Worker: metadata fetch error for id", id, ":", fetchErr)
+	}
+
+	if err := metadata.Save(ctx, dbConn, id, meta, fetchErr); err != nil {
+		fmt.Println("Worker: metadata save error:", err)
+	}
+
+	if meta != nil && links.RedirectsOffsite(longURL, meta.FinalURL) {
+		if err := links.MarkSuspicious(ctx, dbConn, id); err != nil {
+			fmt.Println("Worker: flag error:", err)
+			return
+		}
+		// Drop the cached link so the flag applies right away
+		rdb.Del(ctx, links.CacheKey(links.CodeOf(ctx, dbConn, id)))
+	}
+}
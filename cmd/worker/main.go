@@ -4,15 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
 	"url-shortener/internal/analytics"
+	"url-shortener/internal/config"
 	"url-shortener/internal/db"
 	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+	"url-shortener/internal/metadata"
+	"url-shortener/internal/webhooks"
 )
 
 func main() {
@@ -20,15 +27,19 @@ func main() {
 
 	rdb := db.InitRedis()
 
-	sqlitePath := os.Getenv("SQLITE_PATH")
-	if sqlitePath == "" {
-		sqlitePath = "./urls.db"
-	}
-	sqlite := db.InitSQLite(sqlitePath)
+	cfg := config.Load()
+	sqlite := db.InitSQLite(cfg.SQLitePath)
 	defer sqlite.Close()
 
-	// Subscribe to click events
-	sub := rdb.Subscribe(ctx, analytics.ClickChannel)
+	fetcher := metadata.NewFetcher()
+
+	// Deliver the webhooks queued from click and link events,
+	// and prune old deliveries
+	dispatcher := webhooks.NewDispatcher(sqlite, cfg.WebhookMaxAttempts, cfg.WebhookRetryBase, cfg.WebhookRetention, cfg.WebhookAllowPrivate)
+	go dispatcher.Run(ctx, time.Second)
+
+	// Subscribe to click and link events
+	sub := rdb.Subscribe(ctx, analytics.ClickChannel, analytics.LinkChannel)
 	defer sub.Close()
 
 	channel := sub.Channel()
@@ -37,7 +48,7 @@ func main() {
 	sigChannel := make(chan os.Signal, 1)
 	signal.Notify(sigChannel, syscall.SIGINT, syscall.SIGTERM)
 
-	fmt.Println("Worker: listening for click_events...")
+	fmt.Println("Worker: listening for click_events and link_events...")
 
 runLoop:
 	for {
@@ -46,7 +57,12 @@ runLoop:
 			if !ok {
 				break runLoop
 			}
-			go handleMessage(sqlite, msg.Payload)
+			switch msg.Channel {
+			case analytics.LinkChannel:
+				go handleLinkEvent(sqlite, rdb, fetcher, msg.Payload)
+			default:
+				go handleMessage(sqlite, rdb, msg.Payload)
+			}
 		case s := <-sigChannel:
 			fmt.Println("Worker: signal", s, "shutting down...")
 			break runLoop
@@ -56,7 +72,7 @@ runLoop:
 	fmt.Println("Worker stopped")
 }
 
-func handleMessage(dbConn *sql.DB, payload string) {
+func handleMessage(dbConn *sql.DB, rdb *redis.Client, payload string) {
 	var event events.ClickEvent
 
 	if err := json.Unmarshal([]byte(payload), &event); err != nil {
@@ -64,15 +80,72 @@ func handleMessage(dbConn *sql.DB, payload string) {
 		return
 	}
 
-	_, err := dbConn.Exec(`
+	// Bot clicks are only counted; they don't make a visit
+	if event.Bot != "" {
+		_, err := dbConn.Exec("UPDATE urls SET bot_click_count = bot_click_count + 1 WHERE id = ?", event.ID)
+		if err != nil {
+			fmt.Println("Worker: db update error:", err)
+		}
+		return
+	}
+
+	var creatorID sql.NullInt64
+	err := dbConn.QueryRow(`
 		UPDATE urls
 		SET click_count = COALESCE(click_count, 0) + 1, 
 		    last_visited_at = ?
-		WHERE id = ?`,
+		WHERE id = ?
+		RETURNING creator_id`,
 		event.TS, event.ID,
+	).Scan(&creatorID)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("Worker: db update error:", err)
+	}
+
+	// Links created with an API key notify its webhooks
+	if creatorID.Valid {
+		queueClickWebhooks(dbConn, event, creatorID.Int64)
+	}
+
+	// Unique visitors, counted in Redis
+	if event.Visitor != "" {
+		recordVisitor(rdb, event)
+	}
+
+	// Per-dimension aggregates for targeted links
+	if event.Rule != "" {
+		incrementBreakdown(dbConn, event.ID, "rule", event.Rule)
+	}
+	if event.Country != "" {
+		incrementBreakdown(dbConn, event.ID, "country", event.Country)
+	}
+	if event.Variant != "" {
+		incrementBreakdown(dbConn, event.ID, "variant", event.Variant)
+	}
+}
+
+// recordVisitor adds the click's visitor to the link's unique visitors
+func recordVisitor(rdb *redis.Client, event events.ClickEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := links.RecordVisitor(ctx, rdb, event.ID, event.Visitor, eventTime(event.TS)); err != nil {
+		fmt.Println("Worker: visitor count error:", err)
+	}
+}
+
+// incrementBreakdown counts one click for the given link under
+// dimension/value, e.g. ("rule", "platform:ios")
+func incrementBreakdown(dbConn *sql.DB, id uint64, dimension string, value string) {
+	_, err := dbConn.Exec(`
+		INSERT INTO click_breakdown (url_id, dimension, value, clicks)
+		VALUES (?, ?, ?, 1)
+		ON CONFLICT (url_id, dimension, value) DO UPDATE SET clicks = clicks + 1`,
+		id, dimension, value,
 	)
 
 	if err != nil {
-		fmt.Println("Worker: db update error:", err)
+		fmt.Println("Worker: db breakdown error:", err)
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+	"url-shortener/internal/webhooks"
+)
+
+// queueLinkWebhooks queues a delivery of the link event for every
+// webhook of the link's creator subscribed to it
+func queueLinkWebhooks(dbConn *sql.DB, event events.LinkEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	payload := webhooks.Payload{
+		Type:      event.Type,
+		CreatedAt: eventTime(event.TS),
+		Code:      event.Code,
+	}
+	if event.Type != events.LinkDeleted {
+		// Best effort; the link may be gone already
+		dbConn.QueryRowContext(ctx, "SELECT long_url FROM urls WHERE id = ?", event.ID).Scan(&payload.URL)
+	}
+	if _, err := webhooks.Enqueue(ctx, dbConn, event.CreatorID, payload); err != nil {
+		fmt.Println("Worker: webhook queue error:", err)
+	}
+}
+
+// queueClickWebhooks queues a link.clicked delivery for every
+// webhook of the link's creator subscribed to it
+func queueClickWebhooks(dbConn *sql.DB, event events.ClickEvent, creatorID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	payload := webhooks.Payload{
+		Type:      events.LinkClicked,
+		CreatedAt: eventTime(event.TS),
+		Code:      links.CodeOf(ctx, dbConn, event.ID),
+		Click: &webhooks.Click{
+			Country: event.Country,
+			Variant: event.Variant,
+			Rule:    event.Rule,
+		},
+	}
+	if _, err := webhooks.Enqueue(ctx, dbConn, creatorID, payload); err != nil {
+		fmt.Println("Worker: webhook queue error:", err)
+	}
+}
+
+// eventTime parses an event's timestamp, falling back to now
+func eventTime(ts string) time.Time {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return t
+}
@@ -11,16 +11,17 @@ import (
 	"url-shortener/internal/events"
 )
 
-const ClickChannel = "click_events"
+const (
+	ClickChannel = "click_events"
+	LinkChannel  = "link_events"
+	AdminChannel = "admin_events"
+)
 
 // PublishClickEvent sends a non-blocking event to Redis Pub/Sub
-func PublishClickEvent(rdb *redis.Client, id uint64) {
+func PublishClickEvent(rdb *redis.Client, event events.ClickEvent) {
 	// Runs in the background
 	go func() {
-		event := events.ClickEvent{
-			ID: id,
-			TS: time.Now().UTC().Format(time.RFC3339),
-		}
+		event.TS = time.Now().UTC().Format(time.RFC3339)
 		payload, _ := json.Marshal(event)
 
 		// Create context with timeout to ensure that goroutine never hangs
@@ -29,6 +30,35 @@ func PublishClickEvent(rdb *redis.Client, id uint64) {
 
 		// Publish the event
 		_ = rdb.Publish(ctx, "click_events", payload).Err()
-		log.Println("Published Click event for id:", id)
+		log.Println("Published Click event for id:", event.ID)
+	}()
+}
+
+// PublishLinkEvent sends a non-blocking link lifecycle event to Redis Pub/Sub
+func PublishLinkEvent(rdb *redis.Client, event events.LinkEvent) {
+	// Runs in the background
+	go func() {
+		// Create context with timeout to ensure that goroutine never hangs
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		_ = SendLinkEvent(ctx, rdb, event)
+		log.Println("Published Link event", event.Type, "for id:", event.ID)
 	}()
 }
+
+// SendLinkEvent publishes a link event and waits for Redis to accept it,
+// for short-lived processes like miniurlctl
+func SendLinkEvent(ctx context.Context, rdb *redis.Client, event events.LinkEvent) error {
+	event.TS = time.Now().UTC().Format(time.RFC3339)
+	payload, _ := json.Marshal(event)
+	return rdb.Publish(ctx, LinkChannel, payload).Err()
+}
+
+// SendAdminEvent publishes an admin event and returns
+// how many subscribers (servers) received it
+func SendAdminEvent(ctx context.Context, rdb *redis.Client, event events.AdminEvent) (int64, error) {
+	event.TS = time.Now().UTC().Format(time.RFC3339)
+	payload, _ := json.Marshal(event)
+	return rdb.Publish(ctx, AdminChannel, payload).Result()
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// APIKey identifies who is calling the API. Requests without
+// a key are anonymous.
+type APIKey struct {
+	ID   int64
+	Name string
+
+	// Trusted creators' links skip the safety interstitial and
+	// may opt out of it explicitly
+	Trusted bool
+
+	// BulkRowLimit caps the rows of a bulk upload.
+	// Zero means the server-wide default.
+	BulkRowLimit int
+}
+
+type contextKey struct{}
+
+// Identify returns a middleware that resolves the API key sent in the
+// X-API-Key header (or as "Authorization: Bearer <key>") and stores it
+// in the request context. Unknown keys are rejected; requests without
+// a key go through anonymously.
+func Identify(db *sql.DB) func(http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := keyFromRequest(r)
+			if raw == "" {
+				// Anonymous
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := Lookup(r.Context(), db, raw)
+			if err != nil {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), key)))
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying the caller's API key
+func NewContext(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the caller's API key, or nil if anonymous
+func FromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(contextKey{}).(*APIKey)
+	return key
+}
+
+// Lookup returns the API key matching raw. Only hashes are stored,
+// so a leaked database doesn't leak usable keys.
+func Lookup(ctx context.Context, db *sql.DB, raw string) (*APIKey, error) {
+	var (
+		key          APIKey
+		bulkRowLimit sql.NullInt64
+	)
+	err := db.QueryRowContext(ctx,
+		"SELECT id, name, trusted, bulk_row_limit FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL", hashKey(raw)).
+		Scan(&key.ID, &key.Name, &key.Trusted, &bulkRowLimit)
+	if err != nil {
+		return nil, err
+	}
+	key.BulkRowLimit = int(bulkRowLimit.Int64)
+	return &key, nil
+}
+
+// CreateKey issues a new API key and returns it in plain text.
+// It can't be retrieved again afterwards. A zero bulkRowLimit
+// means the server-wide default.
+func CreateKey(ctx context.Context, db *sql.DB, name string, trusted bool, bulkRowLimit int) (string, error) {
+	if name == "" {
+		return "", errors.New("API key name required")
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	raw := "mu_" + hex.EncodeToString(secret)
+
+	_, err := db.ExecContext(ctx,
+		"INSERT INTO api_keys(name, key_hash, trusted, bulk_row_limit, created_at) VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP)",
+		name, hashKey(raw), trusted, sql.NullInt64{Int64: int64(bulkRowLimit), Valid: bulkRowLimit > 0},
+	)
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// RevokeKey stops the API key with the given id from being accepted.
+// Links created with it are kept.
+func RevokeKey(ctx context.Context, db *sql.DB, id int64) error {
+	res, err := db.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListKeys returns every API key that hasn't been revoked
+func ListKeys(ctx context.Context, db *sql.DB) ([]APIKey, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, name, trusted, bulk_row_limit FROM api_keys WHERE revoked_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var (
+			key          APIKey
+			bulkRowLimit sql.NullInt64
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.Trusted, &bulkRowLimit); err != nil {
+			return nil, err
+		}
+		key.BulkRowLimit = int(bulkRowLimit.Int64)
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+/**** Helper Methods below ****/
+
+func keyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	return ""
+}
+
+func hashKey(raw string) string {
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
@@ -2,6 +2,7 @@ package bloom
 
 import (
 	"database/sql"
+	"sync"
 
 	bf "github.com/bits-and-blooms/bloom/v3"
 )
@@ -9,40 +10,77 @@ import (
 var (
 	Filter  *bf.BloomFilter
 	Enabled bool
+
+	// mu guards Filter, which is swapped out on rebuilds
+	mu sync.RWMutex
+
+	// Estimates the filter was created with, reused on rebuilds
+	filterCapacity uint
+	filterFPRate   float64
 )
 
 func InitBloom(capacity uint, falsePositiveRate float64) {
+	filterCapacity, filterFPRate = capacity, falsePositiveRate
 	Filter = bf.NewWithEstimates(capacity, falsePositiveRate)
 	Enabled = false
 }
 
 func Populate(db *sql.DB) error {
-	rows, err := db.Query("SELECT long_url FROM urls")
-	if err != nil {
+	if _, err := fill(db, Filter); err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	var url string
-	var count uint
+	Enabled = true
+	return nil
+}
 
-	for rows.Next() {
-		if err := rows.Scan(&url); err != nil {
-			continue
-		}
-		Filter.AddString(url)
-		count++
+// Rebuild repopulates a fresh filter from SQLite and swaps it in,
+// dropping keys of deleted links. Returns the number of keys added.
+func Rebuild(db *sql.DB) (uint, error) {
+	fresh := bf.NewWithEstimates(filterCapacity, filterFPRate)
+	count, err := fill(db, fresh)
+	if err != nil {
+		return 0, err
 	}
+
+	mu.Lock()
+	Filter = fresh
 	Enabled = true
-	return rows.Err()
+	mu.Unlock()
+	return count, nil
 }
 
 func Add(url string) {
+	mu.Lock()
+	defer mu.Unlock()
 	if Enabled {
 		Filter.AddString(url)
 	}
 }
 
 func MightExist(url string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
 	return Enabled && Filter.TestString(url)
 }
+
+/**** Helper Methods below ****/
+
+func fill(db *sql.DB, filter *bf.BloomFilter) (uint, error) {
+	rows, err := db.Query("SELECT COALESCE(normalized_url, long_url) FROM urls")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var url string
+	var count uint
+
+	for rows.Next() {
+		if err := rows.Scan(&url); err != nil {
+			continue
+		}
+		filter.AddString(url)
+		count++
+	}
+	return count, rows.Err()
+}
@@ -0,0 +1,118 @@
+package bots
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Reasons a request is classified as automated
+const (
+	ReasonUserAgent = "user-agent"
+	ReasonHead      = "head"
+	ReasonPrefetch  = "prefetch"
+)
+
+// defaultPatterns is the built-in user-agent list,
+// used unless a patterns file is configured
+//
+//go:embed patterns.txt
+var defaultPatterns string
+
+var (
+	mu      sync.RWMutex
+	matcher *regexp.Regexp
+	path    string
+)
+
+// prefetchHeaders are sent by browsers and apps fetching a page
+// ahead of time, rather than because someone opened it
+var prefetchHeaders = []string{"Purpose", "Sec-Purpose", "X-Purpose", "X-Moz"}
+
+// InitBots loads the user-agent patterns from the file at patternsPath,
+// or the built-in list if it is empty, and returns how many there are
+func InitBots(patternsPath string) (int, error) {
+	mu.Lock()
+	path = patternsPath
+	mu.Unlock()
+	return Reload()
+}
+
+// Reload reads the patterns file again, e.g. once operators updated it.
+// The patterns in use are kept if the file can't be read or parsed.
+func Reload() (int, error) {
+	mu.RLock()
+	source := path
+	mu.RUnlock()
+
+	list := defaultPatterns
+	if source != "" {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return 0, err
+		}
+		list = string(data)
+	}
+	compiled, count, err := compile(list)
+	if err != nil {
+		return 0, err
+	}
+
+	mu.Lock()
+	matcher = compiled
+	mu.Unlock()
+	return count, nil
+}
+
+// Classify returns why the request looks automated: a known bot user
+// agent (or none at all), a HEAD request or a prefetch. It returns ""
+// for requests that look like a person following the link.
+func Classify(r *http.Request) string {
+	if r.Method == http.MethodHead {
+		return ReasonHead
+	}
+	for _, name := range prefetchHeaders {
+		value := strings.ToLower(r.Header.Get(name))
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "preview") {
+			return ReasonPrefetch
+		}
+	}
+
+	userAgent := strings.TrimSpace(r.UserAgent())
+	if userAgent == "" {
+		return ReasonUserAgent
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if matcher != nil && matcher.MatchString(userAgent) {
+		return ReasonUserAgent
+	}
+	return ""
+}
+
+/**** Helper Methods below ****/
+
+// compile joins the patterns of a list, one per line,
+// into a single case-insensitive expression
+func compile(list string) (*regexp.Regexp, int, error) {
+	var patterns []string
+	for i, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := regexp.Compile(line); err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		patterns = append(patterns, "(?:"+line+")")
+	}
+	if len(patterns) == 0 {
+		return nil, 0, nil
+	}
+	compiled, err := regexp.Compile("(?i)" + strings.Join(patterns, "|"))
+	return compiled, len(patterns), err
+}
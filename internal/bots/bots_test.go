@@ -0,0 +1,40 @@
+package bots
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyUserAgents(t *testing.T) {
+	if _, err := InitBots(""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		// Link preview fetchers
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", ReasonUserAgent},
+		{"WhatsApp/2.23.20.0 A", ReasonUserAgent},
+		{"Pinterest/0.2 (+https://www.pinterest.com/bot.html)", ReasonUserAgent},
+		{"Mozilla/5.0 (compatible; Pinterestbot/1.0; +http://www.pinterest.com/bot.html)", ReasonUserAgent},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0 Safari/537.36 (compatible; Snap URL Preview Service; bot; snapchat; https://developers.snap.com/robots)", ReasonUserAgent},
+		{"curl/8.5.0", ReasonUserAgent},
+		{"", ReasonUserAgent},
+
+		// People, including in the in-app browsers of the same apps
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", ""},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0 Mobile Safari/537.36 WhatsApp/2.24.1.6", ""},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [Pinterest/iOS]", ""},
+		{"Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36 [Pinterest/Android]", ""},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Snapchat/12.70.0.35 (like Safari/8617.2.4.10.8, panda)", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/6LAzd", nil)
+		r.Header.Set("User-Agent", tt.userAgent)
+		if got := Classify(r); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
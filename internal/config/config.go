@@ -0,0 +1,159 @@
+package config
+
+import (
+	"crypto/rand"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+// Config holds the server-wide settings, read once at startup
+type Config struct {
+	SQLitePath string
+
+	// Normalize controls how long URLs are canonicalised for deduplication
+	Normalize utils.NormalizeOptions
+
+	// DefaultRedirectStatus is used for links created without a redirect type
+	DefaultRedirectStatus int
+
+	// CookieSecret signs the cookies issued after unlocking a protected link
+	CookieSecret []byte
+
+	// VisitorSecret keys the fingerprints unique visitors are counted
+	// by. It is derived from VISITOR_SECRET, or COOKIE_SECRET if unset,
+	// so it never equals the cookie signing key.
+	VisitorSecret []byte
+
+	// GeoIPPath is the MaxMind-format (mmdb) database used to resolve
+	// visitors' countries. Geo targeting is disabled if empty.
+	GeoIPPath string
+
+	// BotPatternsPath is a file of user-agent patterns whose clicks are
+	// counted as bot clicks. The built-in list is used if empty.
+	BotPatternsPath string
+
+	// InterstitialMode is "off", "auto" or "always". In "auto" mode the
+	// safety page is shown for suspicious links and for links anonymous
+	// users created less than InterstitialNewFor ago.
+	InterstitialMode   string
+	InterstitialNewFor time.Duration
+
+	// BulkRowLimit caps the rows of a bulk upload for API keys
+	// without a limit of their own
+	BulkRowLimit int
+
+	// GRPCAddr is where the gRPC API listens, "off" to disable it
+	GRPCAddr string
+
+	// LiveStreamsPerIP caps the live click streams a client IP
+	// may hold open at once
+	LiveStreamsPerIP int
+
+	// Webhook deliveries are retried WebhookMaxAttempts times in all,
+	// waiting WebhookRetryBase, then twice as long after every failure.
+	// Delivered deliveries are pruned once older than WebhookRetention.
+	// Receivers on internal addresses are refused unless
+	// WebhookAllowPrivate is set, e.g. for local development.
+	WebhookMaxAttempts  int
+	WebhookRetryBase    time.Duration
+	WebhookRetention    time.Duration
+	WebhookAllowPrivate bool
+
+	// PublicURL prefixes the short URLs returned over gRPC,
+	// where there is no request Host to build them from
+	PublicURL string
+}
+
+// Load reads the configuration from environment variables,
+// falling back to defaults suitable for local dev
+func Load() *Config {
+	cfg := &Config{
+		SQLitePath: getEnv("SQLITE_PATH", "./urls.db"),
+		Normalize: utils.NormalizeOptions{
+			StripTrackingParams: getEnvBool("NORMALIZE_STRIP_TRACKING", false),
+			SortQuery:           getEnvBool("NORMALIZE_SORT_QUERY", false),
+		},
+		DefaultRedirectStatus: getEnvInt("DEFAULT_REDIRECT_STATUS", http.StatusFound),
+		CookieSecret:          []byte(os.Getenv("COOKIE_SECRET")),
+		VisitorSecret:         []byte(os.Getenv("VISITOR_SECRET")),
+		GeoIPPath:             os.Getenv("GEOIP_DB_PATH"),
+		BotPatternsPath:       os.Getenv("BOT_PATTERNS_PATH"),
+		InterstitialMode:      getEnv("INTERSTITIAL_MODE", links.InterstitialModeAuto),
+		InterstitialNewFor:    getEnvDuration("INTERSTITIAL_NEW_FOR", 24*time.Hour),
+		BulkRowLimit:          getEnvInt("BULK_ROW_LIMIT", 1000),
+		GRPCAddr:              getEnv("GRPC_ADDR", ":9090"),
+		LiveStreamsPerIP:      getEnvInt("LIVE_STREAMS_PER_IP", 5),
+		WebhookMaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:      getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		WebhookRetention:      getEnvDuration("WEBHOOK_RETENTION", 30*24*time.Hour),
+		WebhookAllowPrivate:   getEnvBool("WEBHOOK_ALLOW_PRIVATE", false),
+		PublicURL:             strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost:8080"), "/"),
+	}
+
+	if !links.ValidRedirectType(cfg.DefaultRedirectStatus) {
+		log.Fatal("Invalid DEFAULT_REDIRECT_STATUS: must be one of 301, 302, 307, 308")
+	}
+	switch cfg.InterstitialMode {
+	case links.InterstitialModeOff, links.InterstitialModeAuto, links.InterstitialModeAlways:
+	default:
+		log.Fatal("Invalid INTERSTITIAL_MODE: must be one of off, auto, always")
+	}
+	if len(cfg.VisitorSecret) == 0 && len(cfg.CookieSecret) == 0 {
+		// Returning visitors would silently count as new ones
+		log.Println("WARNING: neither VISITOR_SECRET nor COOKIE_SECRET is set. " +
+			"Visitor fingerprints will change on every restart and differ between servers, " +
+			"inflating unique visitor counts. Set VISITOR_SECRET outside of local dev.")
+	}
+	if len(cfg.CookieSecret) == 0 {
+		// Fine for local dev, but cookies won't survive restarts
+		// or be shared between replicas
+		log.Println("COOKIE_SECRET not set, using a random one")
+		cfg.CookieSecret = make([]byte, 32)
+		rand.Read(cfg.CookieSecret)
+	}
+	if len(cfg.VisitorSecret) == 0 {
+		cfg.VisitorSecret = cfg.CookieSecret
+	}
+	cfg.VisitorSecret = utils.DeriveKey(cfg.VisitorSecret, "visitor")
+	return cfg
+}
+
+/**** Helper Methods below ****/
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,335 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"url-shortener/internal/utils"
+)
+
+// migrations are applied in order, once each. The index+1 of a
+// migration is its schema version, tracked via PRAGMA user_version.
+// Never edit or reorder an existing entry; append a new one instead.
+var migrations = []func(tx *sql.Tx) error{
+	addNormalizedURL,
+	addRedirectType,
+	addPasswordHash,
+	addMaxClicks,
+	addActivationWindow,
+	addTargeting,
+	addVariants,
+	addPassthrough,
+	addCampaign,
+	addLinkMetadata,
+	addCreatorsAndInterstitial,
+	addBulkRowLimit,
+	addLinkCodes,
+	addDisabledAt,
+	addWebhooks,
+	addBotClickCount,
+}
+
+// LatestVersion is the schema version Migrate brings databases to
+func LatestVersion() int {
+	return len(migrations)
+}
+
+// Version returns the database's current schema version
+func Version(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// Migrate brings the schema up to the latest version. db must come from
+// Open: each step reads the version in a transaction holding the write
+// lock, so concurrent callers never apply the same step twice.
+func Migrate(db *sql.DB) error {
+	for {
+		done, err := migrateStep(db)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+/**** Helper Methods below ****/
+
+// migrateStep applies the next migration, if any.
+// It reports whether the schema was up to date.
+func migrateStep(db *sql.DB) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return false, err
+	}
+	if version >= len(migrations) {
+		return true, nil
+	}
+
+	if err := migrations[version](tx); err != nil {
+		return false, fmt.Errorf("migration %d: %w", version+1, err)
+	}
+	// PRAGMA doesn't accept bound parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+		return false, err
+	}
+	return false, tx.Commit()
+}
+
+/**** Migrations below ****/
+
+// addNormalizedURL adds the dedup key column. SQLite can't drop the
+// UNIQUE constraint on long_url in place, so the table is rebuilt.
+func addNormalizedURL(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE urls_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			long_url TEXT NOT NULL,
+			normalized_url TEXT,
+			click_count INTEGER DEFAULT 0,
+			last_visited_at DATETIME
+		);
+		INSERT INTO urls_new (id, long_url, click_count, last_visited_at)
+			SELECT id, long_url, click_count, last_visited_at FROM urls;
+		DROP TABLE urls;
+		ALTER TABLE urls_new RENAME TO urls;
+		CREATE INDEX idx_urls_normalized_url ON urls(normalized_url);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Backfill with the base normalisation only; rows that would differ
+	// under the optional steps simply miss dedup and get a new link
+	rows, err := tx.Query("SELECT id, long_url FROM urls")
+	if err != nil {
+		return err
+	}
+	keys := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id      int64
+			longURL string
+		)
+		if err := rows.Scan(&id, &longURL); err != nil {
+			rows.Close()
+			return err
+		}
+		if key, err := utils.NormalizeURL(longURL, utils.NormalizeOptions{}); err == nil {
+			keys[id] = key
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, key := range keys {
+		if _, err := tx.Exec("UPDATE urls SET normalized_url = ? WHERE id = ?", key, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRedirectType stores the per-link redirect status (NULL -> server default)
+func addRedirectType(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE urls ADD COLUMN redirect_type INTEGER")
+	return err
+}
+
+// addPasswordHash stores the bcrypt hash of protected links' passwords
+func addPasswordHash(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE urls ADD COLUMN password_hash TEXT")
+	return err
+}
+
+// addMaxClicks stores the click budget of self-destructing links (NULL -> unlimited)
+func addMaxClicks(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE urls ADD COLUMN max_clicks INTEGER")
+	return err
+}
+
+// addActivationWindow stores the optional schedule of a link and
+// where to send visitors outside of it
+func addActivationWindow(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE urls ADD COLUMN not_before DATETIME;
+		ALTER TABLE urls ADD COLUMN not_after DATETIME;
+		ALTER TABLE urls ADD COLUMN fallback_url TEXT;
+	`)
+	return err
+}
+
+// addTargeting stores per-link routing rules and the worker's
+// per-dimension click aggregates (e.g. clicks per matched rule)
+func addTargeting(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE link_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL,
+			value TEXT NOT NULL,
+			destination TEXT NOT NULL,
+			position INTEGER NOT NULL
+		);
+		CREATE INDEX idx_link_rules_url_id ON link_rules(url_id);
+
+		CREATE TABLE click_breakdown (
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			dimension TEXT NOT NULL,
+			value TEXT NOT NULL,
+			clicks INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (url_id, dimension, value)
+		);
+	`)
+	return err
+}
+
+// addVariants stores the weighted destinations of A/B split links
+func addVariants(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE link_variants (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			destination TEXT NOT NULL,
+			weight INTEGER NOT NULL,
+			position INTEGER NOT NULL
+		);
+		CREATE INDEX idx_link_variants_url_id ON link_variants(url_id);
+	`)
+	return err
+}
+
+// addPassthrough stores whether a link forwards the visitor's
+// query string and extra path segments to its destination
+func addPassthrough(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE urls ADD COLUMN pass_query BOOLEAN NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN pass_path BOOLEAN NOT NULL DEFAULT 0;
+	`)
+	return err
+}
+
+// addCampaign stores the UTM params campaign links were built with
+func addCampaign(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE urls ADD COLUMN utm_source TEXT;
+		ALTER TABLE urls ADD COLUMN utm_medium TEXT;
+		ALTER TABLE urls ADD COLUMN utm_campaign TEXT;
+		ALTER TABLE urls ADD COLUMN utm_term TEXT;
+		ALTER TABLE urls ADD COLUMN utm_content TEXT;
+		CREATE INDEX idx_urls_utm_campaign ON urls(utm_campaign);
+	`)
+	return err
+}
+
+// addLinkMetadata stores what the worker fetched from each
+// link's destination, for richer previews
+func addLinkMetadata(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE link_metadata (
+			url_id INTEGER PRIMARY KEY REFERENCES urls(id) ON DELETE CASCADE,
+			title TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			image_url TEXT NOT NULL DEFAULT '',
+			status_code INTEGER NOT NULL DEFAULT 0,
+			final_url TEXT NOT NULL DEFAULT '',
+			error TEXT,
+			fetched_at DATETIME NOT NULL
+		);
+	`)
+	return err
+}
+
+// addCreatorsAndInterstitial adds API keys, records who created each
+// link and when, and the settings driving the safety interstitial
+func addCreatorsAndInterstitial(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			trusted BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			revoked_at DATETIME
+		);
+
+		ALTER TABLE urls ADD COLUMN created_at DATETIME;
+		ALTER TABLE urls ADD COLUMN creator_id INTEGER REFERENCES api_keys(id);
+		ALTER TABLE urls ADD COLUMN interstitial TEXT;
+		ALTER TABLE urls ADD COLUMN suspicious BOOLEAN NOT NULL DEFAULT 0;
+	`)
+	return err
+}
+
+// addBulkRowLimit lets each API key have its own bulk upload limit
+func addBulkRowLimit(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE api_keys ADD COLUMN bulk_row_limit INTEGER`)
+	return err
+}
+
+// addLinkCodes keeps the codes of links imported from other shorteners
+func addLinkCodes(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE link_codes (
+			code TEXT PRIMARY KEY,
+			url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE
+		);
+		CREATE INDEX idx_link_codes_url_id ON link_codes(url_id);
+	`)
+	return err
+}
+
+// addDisabledAt lets operators disable links without deleting them
+func addDisabledAt(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE urls ADD COLUMN disabled_at DATETIME`)
+	return err
+}
+
+// addWebhooks stores API keys' webhook subscriptions and every delivery
+// made to them. Deliveries that ran out of retries are kept as dead.
+func addWebhooks(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			api_key_id INTEGER NOT NULL REFERENCES api_keys(id),
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		);
+		CREATE INDEX idx_webhooks_api_key_id ON webhooks(api_key_id);
+
+		CREATE TABLE webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER,
+			error TEXT,
+			created_at DATETIME NOT NULL,
+			next_attempt_at DATETIME,
+			delivered_at DATETIME
+		);
+		CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+		CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id);
+	`)
+	return err
+}
+
+// addBotClickCount counts the clicks of crawlers, unfurlers and uptime
+// checkers apart from click_count
+func addBotClickCount(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE urls ADD COLUMN bot_click_count INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
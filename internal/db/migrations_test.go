@@ -0,0 +1,55 @@
+package db
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestMigrateConcurrently runs Migrate from two connection pools at once,
+// like the server and worker starting together on the same database
+func TestMigrateConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.db")
+
+	setup, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setup.Close()
+	_, err = setup.Exec(`
+		CREATE TABLE urls (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			long_url TEXT NOT NULL UNIQUE,
+			click_count INTEGER DEFAULT 0,
+			last_visited_at DATETIME
+		)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		conn, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = Migrate(conn)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Migrate #%d: %v", i+1, err)
+		}
+	}
+	if version, err := Version(setup); err != nil || version != LatestVersion() {
+		t.Errorf("Version = %d, %v, want %d", version, err, LatestVersion())
+	}
+}
@@ -3,12 +3,25 @@ package db
 import (
 	"database/sql"
 	"log"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Open opens the SQLite database at path. Transactions take the write
+// lock when they begin (BEGIN IMMEDIATE), so two processes migrating the
+// same file, e.g. the server and worker starting together, take turns
+// instead of failing once both try to write.
+func Open(path string) (*sql.DB, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return sql.Open("sqlite3", path+separator+"_txlock=immediate")
+}
+
 func InitSQLite(path string) *sql.DB {
-	db, err := sql.Open("sqlite3", path)
+	db, err := Open(path)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -26,5 +39,9 @@ func InitSQLite(path string) *sql.DB {
 		log.Fatal("Failed to create table:", err)
 	}
 
+	if err := Migrate(db); err != nil {
+		log.Fatal("Failed to migrate schema:", err)
+	}
+
 	return db
 }
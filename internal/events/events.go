@@ -3,4 +3,61 @@ package events
 type ClickEvent struct {
 	ID uint64 `json:"id"`
 	TS string `json:"ts"`
+
+	// Rule is the targeting rule that picked the destination
+	// ("default" if none matched), empty for untargeted links
+	Rule string `json:"rule,omitempty"`
+
+	// Country is the visitor's ISO country code, if GeoIP is enabled
+	Country string `json:"country,omitempty"`
+
+	// Variant is the A/B variant the visitor was sent to, if any
+	Variant string `json:"variant,omitempty"`
+
+	// CreatorID is the API key the link was created with, if any
+	CreatorID int64 `json:"creator_id,omitempty"`
+
+	// Visitor is the visitor's fingerprint, see links.Fingerprint
+	Visitor string `json:"visitor,omitempty"`
+
+	// Bot is why the click was classified as automated (see bots.Classify),
+	// empty for people. Bot clicks are counted apart from the others.
+	Bot string `json:"bot,omitempty"`
+}
+
+// Link event types
+const (
+	LinkCreated  = "link.created"
+	LinkDisabled = "link.disabled"
+	LinkEnabled  = "link.enabled"
+	LinkDeleted  = "link.deleted"
+
+	// LinkClicked is only used for webhooks; clicks
+	// are published as ClickEvents
+	LinkClicked = "link.clicked"
+)
+
+// LinkEvent announces a change to a link, e.g. so the worker can
+// fetch the destination's metadata once a link is created
+type LinkEvent struct {
+	Type string `json:"type"`
+	ID   uint64 `json:"id"`
+	TS   string `json:"ts"`
+
+	// Code and creator of the link, which are gone once it is deleted
+	Code      string `json:"code,omitempty"`
+	CreatorID int64  `json:"creator_id,omitempty"`
+}
+
+// Admin event types
+const (
+	BloomRebuild = "bloom.rebuild"
+	BotsReload   = "bots.reload"
+)
+
+// AdminEvent asks every server to act on an operator's request,
+// e.g. rebuilding its in-memory Bloom filter
+type AdminEvent struct {
+	Type string `json:"type"`
+	TS   string `json:"ts"`
 }
@@ -0,0 +1,57 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var (
+	Reader  *maxminddb.Reader
+	Enabled bool
+)
+
+// record holds the only field we read from a MaxMind-format
+// database; both GeoLite2-Country and GeoLite2-City provide it
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// InitGeoIP opens the mmdb file at path. Geo lookups stay
+// disabled if no path is configured or the file can't be read.
+func InitGeoIP(path string) error {
+	Enabled = false
+	if path == "" {
+		return nil
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return err
+	}
+	Reader = reader
+	Enabled = true
+	return nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code (e.g. "US") of the
+// country ip belongs to, or "" if it's unknown
+func Country(ip string) string {
+	if !Enabled {
+		return ""
+	}
+
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+
+	var rec record
+	if err := Reader.Lookup(parsed, &rec); err != nil {
+		return ""
+	}
+	return strings.ToUpper(rec.Country.ISOCode)
+}
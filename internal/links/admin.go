@@ -0,0 +1,147 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// TopLink is a link ranked by clicks
+type TopLink struct {
+	ID         uint64
+	LongURL    string
+	ClickCount int64
+}
+
+// SetDisabled disables or re-enables the link with the given id
+func SetDisabled(ctx context.Context, db *sql.DB, id uint64, disabled bool) error {
+	var disabledAt sql.NullTime
+	if disabled {
+		disabledAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+	res, err := db.ExecContext(ctx, "UPDATE urls SET disabled_at = ? WHERE id = ?", disabledAt, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// Delete removes the link with the given id and everything stored with it
+func Delete(ctx context.Context, db *sql.DB, id uint64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Foreign keys aren't enforced, so ON DELETE CASCADE doesn't apply
+	for _, table := range []string{"link_rules", "link_variants", "click_breakdown", "link_metadata", "link_codes"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE url_id = ?", id); err != nil {
+			return err
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM urls WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DedupKey returns the stored dedup key of the link, empty if it isn't plain
+func DedupKey(ctx context.Context, db *sql.DB, id uint64) (string, error) {
+	var dedupKey sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT normalized_url FROM urls WHERE id = ?", id).Scan(&dedupKey)
+	return dedupKey.String, err
+}
+
+// ClickStats returns how often the link was followed and when last
+func ClickStats(ctx context.Context, db *sql.DB, id uint64) (int64, *time.Time, error) {
+	var (
+		clickCount  sql.NullInt64
+		lastVisited sql.NullTime
+	)
+	err := db.QueryRowContext(ctx, "SELECT click_count, last_visited_at FROM urls WHERE id = ?", id).
+		Scan(&clickCount, &lastVisited)
+	if err != nil {
+		return 0, nil, err
+	}
+	return clickCount.Int64, timePtr(lastVisited), nil
+}
+
+// BotClicks returns how often the link was followed by crawlers,
+// unfurlers and the like, which ClickStats leaves out
+func BotClicks(ctx context.Context, db *sql.DB, id uint64) (int64, error) {
+	var botClicks int64
+	err := db.QueryRowContext(ctx, "SELECT bot_click_count FROM urls WHERE id = ?", id).Scan(&botClicks)
+	return botClicks, err
+}
+
+// BreakdownRow is the click count of one value of a dimension,
+// e.g. variant "A" of a split link
+type BreakdownRow struct {
+	Value  string
+	Clicks int
+}
+
+// Breakdown returns the link's clicks per value of dimension
+// ("rule", "country" or "variant"), ordered by value
+func Breakdown(ctx context.Context, db *sql.DB, id uint64, dimension string) ([]BreakdownRow, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT value, clicks FROM click_breakdown WHERE url_id = ? AND dimension = ? ORDER BY value",
+		id, dimension,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breakdown []BreakdownRow
+	for rows.Next() {
+		var row BreakdownRow
+		if err := rows.Scan(&row.Value, &row.Clicks); err != nil {
+			return nil, err
+		}
+		breakdown = append(breakdown, row)
+	}
+	return breakdown, rows.Err()
+}
+
+// Top returns the n most clicked enabled links
+func Top(ctx context.Context, db *sql.DB, n int) ([]TopLink, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, long_url, IFNULL(click_count, 0) FROM urls
+		WHERE disabled_at IS NULL
+		ORDER BY click_count DESC, id
+		LIMIT ?`, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var top []TopLink
+	for rows.Next() {
+		var link TopLink
+		if err := rows.Scan(&link.ID, &link.LongURL, &link.ClickCount); err != nil {
+			return nil, err
+		}
+		top = append(top, link)
+	}
+	return top, rows.Err()
+}
+
+/**** Helper Methods below ****/
+
+// requireRow turns updates of a missing link into sql.ErrNoRows
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
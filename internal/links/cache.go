@@ -0,0 +1,77 @@
+package links
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/utils"
+)
+
+// CacheTTL is how long links stay cached in Redis
+const CacheTTL = 24 * time.Hour
+
+// CacheKey is the Redis key the link with the given code is cached under
+func CacheKey(code string) string {
+	return "code_to_link:" + code
+}
+
+// DedupCacheKey is the Redis key mapping a plain link's dedup key to its
+// id. Links are only shared between links of the same creator.
+func DedupCacheKey(creatorID int64, dedupKey string) string {
+	if creatorID == 0 {
+		return "long_to_id:" + utils.HashURL(dedupKey)
+	}
+	return fmt.Sprintf("long_to_id:%d:%s", creatorID, utils.HashURL(dedupKey))
+}
+
+// BudgetKey is the Redis key counting the clicks of a click-limited link
+func BudgetKey(id uint64) string {
+	return fmt.Sprintf("clicks_used:%d", id)
+}
+
+// cachedLink is how links are stored in Redis. Unlike a Link's own
+// JSON, it carries the password hash the redirect handler checks.
+type cachedLink struct {
+	*Link
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// Cache stores the link under its code
+func Cache(ctx context.Context, rdb *redis.Client, code string, link *Link) {
+	payload, _ := json.Marshal(cachedLink{link, link.PasswordHash})
+	_ = rdb.Set(ctx, CacheKey(code), payload, CacheTTL).Err()
+}
+
+// Cached returns the link cached under code, or redis.Nil if there is none
+func Cached(ctx context.Context, rdb *redis.Client, code string) (*Link, error) {
+	payload, err := rdb.Get(ctx, CacheKey(code)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	cached := cachedLink{Link: &Link{}}
+	if err := json.Unmarshal(payload, &cached); err != nil {
+		return nil, err
+	}
+	cached.Link.PasswordHash = cached.PasswordHash
+	return cached.Link, nil
+}
+
+// CacheDedup stores the dedup key -> id mapping of a plain link
+func CacheDedup(ctx context.Context, rdb *redis.Client, link *Link, dedupKey string) {
+	_ = rdb.Set(ctx, DedupCacheKey(link.CreatorID, dedupKey), fmt.Sprint(link.ID), CacheTTL).Err()
+}
+
+// Uncache drops everything cached about the link, so that changes made
+// directly in SQLite apply right away. The click budget is kept: it is
+// reseeded from SQLite if missing, but may be ahead of it.
+func Uncache(ctx context.Context, rdb *redis.Client, code string, link *Link, dedupKey string) error {
+	keys := []string{CacheKey(code)}
+	if dedupKey != "" {
+		keys = append(keys, DedupCacheKey(link.CreatorID, dedupKey))
+	}
+	return rdb.Del(ctx, keys...).Err()
+}
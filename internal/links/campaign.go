@@ -0,0 +1,111 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+)
+
+// Campaign holds the UTM parameters a link was built with
+type Campaign struct {
+	Source  string `json:"source,omitempty"`
+	Medium  string `json:"medium,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Term    string `json:"term,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// CampaignGroupings maps the supported group_by values of
+// CampaignStats to their columns
+var CampaignGroupings = map[string]string{
+	"campaign": "utm_campaign",
+	"source":   "utm_source",
+	"medium":   "utm_medium",
+}
+
+// CampaignStat is the aggregate of all links sharing one value
+// of the grouping column
+type CampaignStat struct {
+	Group  string `json:"group"`
+	Links  int    `json:"links"`
+	Clicks int    `json:"clicks"`
+}
+
+// IsZero reports whether no campaign field is set
+func (c Campaign) IsZero() bool {
+	return c == Campaign{}
+}
+
+// Validate checks the fields Google Analytics needs to attribute visits
+func (c Campaign) Validate() error {
+	if c.Source == "" || c.Name == "" {
+		return errors.New("Campaign source and name are required")
+	}
+	return nil
+}
+
+// Apply returns rawURL with the campaign's utm_* params set,
+// replacing any the URL already had
+func (c Campaign) Apply(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for key, value := range map[string]string{
+		"utm_source":   c.Source,
+		"utm_medium":   c.Medium,
+		"utm_campaign": c.Name,
+		"utm_term":     c.Term,
+		"utm_content":  c.Content,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// CampaignStats returns link and click totals of the API key's campaign
+// links matching filter, grouped by one of CampaignGroupings
+func CampaignStats(ctx context.Context, db *sql.DB, creatorID int64, filter Campaign, groupBy string) ([]CampaignStat, error) {
+	column, ok := CampaignGroupings[groupBy]
+	if !ok {
+		return nil, errors.New("Invalid grouping")
+	}
+
+	// column comes from the allowlist above, never from user input
+	query := "SELECT COALESCE(" + column + ", ''), COUNT(*), COALESCE(SUM(click_count), 0) " +
+		"FROM urls WHERE utm_source IS NOT NULL AND creator_id = ?"
+	args := []any{creatorID}
+	for col, value := range map[string]string{
+		"utm_campaign": filter.Name,
+		"utm_source":   filter.Source,
+		"utm_medium":   filter.Medium,
+	} {
+		if value != "" {
+			query += " AND " + col + " = ?"
+			args = append(args, value)
+		}
+	}
+	query += " GROUP BY 1 ORDER BY 3 DESC, 1"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []CampaignStat
+	for rows.Next() {
+		var stat CampaignStat
+		if err := rows.Scan(&stat.Group, &stat.Links, &stat.Clicks); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
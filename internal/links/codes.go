@@ -0,0 +1,74 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"url-shortener/internal/utils"
+)
+
+// maxIssuedID is the largest id utils.Base62Encode can encode
+const maxIssuedID = 100_000_000
+
+// importedCodePattern is what codes preserved from other shorteners
+// may look like, so that they stay usable as a path segment
+var importedCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// IsIssuedCode reports whether code belongs to the codes this shortener
+// hands out itself, i.e. it is the Base62 encoding of a link id
+func IsIssuedCode(code string) bool {
+	id := utils.Base62Decode(code)
+	return id > 0 && id < maxIssuedID && utils.Base62Encode(id) == code
+}
+
+// ValidateImportedCode checks that code can be preserved for an imported
+// link. Issued codes, e.g. from an export of this shortener, are allowed
+// as long as CodeReserved doesn't report them.
+func ValidateImportedCode(code string) error {
+	if !importedCodePattern.MatchString(code) {
+		return errors.New("Code must be 1-64 letters, digits, '-' or '_'")
+	}
+	return nil
+}
+
+// CodeReserved reports whether code is an issued code whose link id
+// hasn't been handed out yet. A link created here would get it later,
+// so it can't be preserved for an imported link.
+func CodeReserved(ctx context.Context, db *sql.DB, code string) (bool, error) {
+	if !IsIssuedCode(code) {
+		return false, nil
+	}
+
+	// AUTOINCREMENT never hands out an id at or below the sequence again
+	var seq uint64
+	err := db.QueryRowContext(ctx,
+		"SELECT COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'urls'), 0)").Scan(&seq)
+	if err != nil {
+		return false, err
+	}
+	return utils.Base62Decode(code) > seq, nil
+}
+
+// LookupCode returns the id of the link with the given code, which is
+// either one preserved by an import or an issued code
+func LookupCode(ctx context.Context, db *sql.DB, code string) (uint64, error) {
+	var id uint64
+	err := db.QueryRowContext(ctx, "SELECT url_id FROM link_codes WHERE code = ?", code).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) && IsIssuedCode(code) {
+		return utils.Base62Decode(code), nil
+	}
+	return id, err
+}
+
+// CodeOf returns the code visitors use for the link: the preserved
+// code of an imported link, or its issued code otherwise
+func CodeOf(ctx context.Context, db *sql.DB, id uint64) string {
+	var code string
+	err := db.QueryRowContext(ctx, "SELECT code FROM link_codes WHERE url_id = ?", id).Scan(&code)
+	if err != nil {
+		return utils.Base62Encode(id)
+	}
+	return code
+}
@@ -0,0 +1,306 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Link is a short link along with the options it was created with
+type Link struct {
+	ID      uint64 `json:"id"`
+	LongURL string `json:"long_url"`
+
+	// RedirectType is the HTTP status used to redirect visitors.
+	// Zero means "use the server-wide default".
+	RedirectType int `json:"redirect_type,omitempty"`
+
+	// PasswordHash is the bcrypt hash of the link's password, if any.
+	// It is never encoded; the Redis cache stores it separately.
+	PasswordHash string `json:"-"`
+
+	// MaxClicks is the number of times the link can be followed
+	// before it expires. Zero means unlimited.
+	MaxClicks int64 `json:"max_clicks,omitempty"`
+
+	// NotBefore and NotAfter bound the link's activation window.
+	// Outside of it visitors get FallbackURL or an informational page.
+	NotBefore   *time.Time `json:"not_before,omitempty"`
+	NotAfter    *time.Time `json:"not_after,omitempty"`
+	FallbackURL string     `json:"fallback_url,omitempty"`
+
+	// Rules route matching visitors to other destinations, in order
+	Rules []Rule `json:"rules,omitempty"`
+
+	// Variants split visitors matching no rule across weighted
+	// destinations (A/B testing). Empty means LongURL for everyone.
+	Variants []Variant `json:"variants,omitempty"`
+
+	// PassQuery merges the visitor's query string into the destination.
+	// PassPath appends anything after /{code}/ to the destination's path.
+	PassQuery bool `json:"pass_query,omitempty"`
+	PassPath  bool `json:"pass_path,omitempty"`
+
+	// Campaign holds the UTM params LongURL was built with, if any
+	Campaign Campaign `json:"campaign,omitzero"`
+
+	// CreatedAt is unknown (zero) for links older than the column
+	CreatedAt time.Time `json:"created_at,omitzero"`
+
+	// CreatorID is the API key the link was created with.
+	// Zero means an anonymous creator.
+	CreatorID      int64 `json:"creator_id,omitempty"`
+	CreatorTrusted bool  `json:"creator_trusted,omitempty"`
+
+	// Interstitial overrides whether visitors see the safety page
+	// first. Empty means decided by the server-wide setting.
+	Interstitial string `json:"interstitial,omitempty"`
+
+	// Suspicious is set when the destination looks deceptive
+	Suspicious bool `json:"suspicious,omitempty"`
+
+	// Disabled links are kept but can no longer be followed
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// IsPlain reports whether the link was created without any options.
+// Only plain links take part in deduplication, so that e.g. a 301 link
+// is never handed out to someone asking for a 302 one.
+func (l *Link) IsPlain() bool {
+	return l.RedirectType == 0 && !l.IsProtected() && !l.IsVolatile() &&
+		!l.PassQuery && !l.PassPath && l.Campaign.IsZero() && l.Interstitial == ""
+}
+
+// IsVolatile reports whether following the link may lead somewhere
+// else over time, or needs a password, in which case its redirect must
+// never be cached: a shared cache would hand it to anyone
+func (l *Link) IsVolatile() bool {
+	return l.MaxClicks > 0 || l.NotBefore != nil || l.NotAfter != nil ||
+		len(l.Rules) > 0 || len(l.Variants) > 0 || l.IsProtected()
+}
+
+// ActiveAt reports whether t falls inside the link's activation window
+func (l *Link) ActiveAt(t time.Time) bool {
+	if l.NotBefore != nil && t.Before(*l.NotBefore) {
+		return false
+	}
+	if l.NotAfter != nil && !t.Before(*l.NotAfter) {
+		return false
+	}
+	return true
+}
+
+// IsProtected reports whether visitors need a password to follow the link
+func (l *Link) IsProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// SetPassword stores a slow hash of password on the link
+func (l *Link) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	l.PasswordHash = string(hash)
+	return nil
+}
+
+// CheckPassword reports whether password matches the link's password
+func (l *Link) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(password)) == nil
+}
+
+// ValidRedirectType reports whether status can be used to redirect a link
+func ValidRedirectType(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// IsPermanentRedirect reports whether browsers may cache the redirect
+func IsPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// Get loads the link with the given id from SQLite
+func Get(ctx context.Context, db *sql.DB, id uint64) (*Link, error) {
+	var (
+		link         Link
+		redirectType sql.NullInt64
+		passwordHash sql.NullString
+		maxClicks    sql.NullInt64
+		notBefore    sql.NullTime
+		notAfter     sql.NullTime
+		fallbackURL  sql.NullString
+		utm          [5]sql.NullString
+		createdAt    sql.NullTime
+		creatorID    sql.NullInt64
+		trusted      sql.NullBool
+		interstitial sql.NullString
+	)
+	err := db.QueryRowContext(ctx, `
+		SELECT urls.id, long_url, redirect_type, password_hash, max_clicks,
+		       not_before, not_after, fallback_url, pass_query, pass_path,
+		       utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+		       urls.created_at, creator_id, api_keys.trusted, interstitial, suspicious,
+		       disabled_at IS NOT NULL
+		FROM urls LEFT JOIN api_keys ON api_keys.id = urls.creator_id
+		WHERE urls.id = ?`, id).
+		Scan(&link.ID, &link.LongURL, &redirectType, &passwordHash, &maxClicks,
+			&notBefore, &notAfter, &fallbackURL, &link.PassQuery, &link.PassPath,
+			&utm[0], &utm[1], &utm[2], &utm[3], &utm[4],
+			&createdAt, &creatorID, &trusted, &interstitial, &link.Suspicious,
+			&link.Disabled)
+	if err != nil {
+		return nil, err
+	}
+	link.RedirectType = int(redirectType.Int64)
+	link.PasswordHash = passwordHash.String
+	link.MaxClicks = maxClicks.Int64
+	link.NotBefore = timePtr(notBefore)
+	link.NotAfter = timePtr(notAfter)
+	link.FallbackURL = fallbackURL.String
+	link.Campaign = Campaign{
+		Source:  utm[0].String,
+		Medium:  utm[1].String,
+		Name:    utm[2].String,
+		Term:    utm[3].String,
+		Content: utm[4].String,
+	}
+	if t := timePtr(createdAt); t != nil {
+		link.CreatedAt = *t
+	}
+	link.CreatorID = creatorID.Int64
+	link.CreatorTrusted = trusted.Bool
+	link.Interstitial = interstitial.String
+
+	if link.Rules, err = loadRules(ctx, db, link.ID); err != nil {
+		return nil, err
+	}
+	if link.Variants, err = loadVariants(ctx, db, link.ID); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindPlain returns the oldest enabled plain link with the given dedup key.
+// Links are only shared between links of the same creator, zero
+// meaning anonymous ones.
+func FindPlain(ctx context.Context, db *sql.DB, dedupKey string, creatorID int64) (*Link, error) {
+	var id uint64
+	err := db.QueryRowContext(ctx,
+		`SELECT id FROM urls
+		WHERE normalized_url = ? AND IFNULL(creator_id, 0) = ? AND disabled_at IS NULL
+		ORDER BY id LIMIT 1`,
+		dedupKey, creatorID).
+		Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return Get(ctx, db, id)
+}
+
+// Create inserts a new link and sets its ID. The dedup key is only
+// stored for plain links, so that links with options are never reused.
+func Create(ctx context.Context, db *sql.DB, link *Link, dedupKey string) error {
+	return CreateMany(ctx, db, []*Link{link}, []string{dedupKey})
+}
+
+// CreateMany inserts the links in a single transaction and sets their
+// IDs. dedupKeys[i] is the dedup key of batch[i]. Either all links are
+// created or none.
+func CreateMany(ctx context.Context, db *sql.DB, batch []*Link, dedupKeys []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids := make([]uint64, len(batch))
+	for i, link := range batch {
+		if ids[i], err = insertLink(ctx, tx, link, dedupKeys[i]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i, link := range batch {
+		link.ID = ids[i]
+	}
+	return nil
+}
+
+/**** Helper Methods below ****/
+
+func insertLink(ctx context.Context, tx *sql.Tx, link *Link, dedupKey string) (uint64, error) {
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	var normalizedURL sql.NullString
+	if link.IsPlain() {
+		normalizedURL = sql.NullString{String: dedupKey, Valid: true}
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO urls(long_url, normalized_url, redirect_type, password_hash, max_clicks,
+		                  not_before, not_after, fallback_url, pass_query, pass_path,
+		                  utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+		                  created_at, creator_id, interstitial, suspicious)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		link.LongURL, normalizedURL, nullInt(int64(link.RedirectType)), nullString(link.PasswordHash),
+		nullInt(link.MaxClicks), nullTime(link.NotBefore), nullTime(link.NotAfter),
+		nullString(link.FallbackURL), link.PassQuery, link.PassPath,
+		nullString(link.Campaign.Source), nullString(link.Campaign.Medium), nullString(link.Campaign.Name),
+		nullString(link.Campaign.Term), nullString(link.Campaign.Content),
+		link.CreatedAt.UTC(), nullInt(link.CreatorID), nullString(link.Interstitial), link.Suspicious,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := insertRules(ctx, tx, uint64(id), link.Rules); err != nil {
+		return 0, err
+	}
+	if err := insertVariants(ctx, tx, uint64(id), link.Variants); err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// nullInt stores zero values as NULL
+func nullInt(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+// nullString stores empty strings as NULL
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// nullTime stores nil times as NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
@@ -0,0 +1,48 @@
+package links
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLinkJSONOmitsPasswordHash(t *testing.T) {
+	link := &Link{ID: 1, LongURL: "https://example.com/"}
+	if err := link.SetPassword("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(payload), "password") || strings.Contains(string(payload), link.PasswordHash) {
+		t.Errorf("link JSON leaks the password hash: %s", payload)
+	}
+
+	// The Redis cache keeps it, so cached links stay protected
+	payload, err = json.Marshal(cachedLink{link, link.PasswordHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := cachedLink{Link: &Link{}}
+	if err := json.Unmarshal(payload, &cached); err != nil {
+		t.Fatal(err)
+	}
+	if cached.PasswordHash != link.PasswordHash || cached.Link.LongURL != link.LongURL {
+		t.Errorf("cached link = %+v, %q; want the original link and hash", cached.Link, cached.PasswordHash)
+	}
+}
+
+func TestProtectedLinksAreVolatile(t *testing.T) {
+	link := &Link{LongURL: "https://example.com/", RedirectType: 301}
+	if link.IsVolatile() {
+		t.Fatal("plain link is volatile")
+	}
+	if err := link.SetPassword("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if !link.IsVolatile() {
+		t.Error("protected link isn't volatile, so its redirect may be cached")
+	}
+}
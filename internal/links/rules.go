@@ -0,0 +1,103 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+
+	"url-shortener/internal/utils"
+)
+
+// Rule kinds
+const (
+	RulePlatform = "platform"
+	RuleCountry  = "country"
+)
+
+// Targetable platforms, most specific first. "mobile" and
+// "desktop" match any platform of that form factor.
+var Platforms = []string{
+	utils.PlatformIOS, utils.PlatformAndroid,
+	utils.PlatformWindows, utils.PlatformMacOS, utils.PlatformLinux,
+	"mobile", "desktop",
+}
+
+// Rule sends visitors matching Kind/Value to Destination
+// instead of the link's default LongURL
+type Rule struct {
+	Kind        string `json:"kind"`
+	Value       string `json:"value"`
+	Destination string `json:"destination"`
+}
+
+// Name identifies the rule in click analytics, e.g. "platform:ios"
+func (r Rule) Name() string {
+	return r.Kind + ":" + r.Value
+}
+
+// Visitor holds what rules can be matched against
+type Visitor struct {
+	Platform string
+	Mobile   bool
+	Country  string // ISO code, "" if unknown
+}
+
+func (r Rule) matches(v Visitor) bool {
+	switch r.Kind {
+	case RulePlatform:
+		switch r.Value {
+		case "mobile":
+			return v.Mobile
+		case "desktop":
+			return !v.Mobile
+		}
+		return r.Value == v.Platform
+	case RuleCountry:
+		return v.Country != "" && r.Value == v.Country
+	}
+	return false
+}
+
+// Resolve returns where to send v: the destination of the first
+// matching rule, or the link's default LongURL if none matches
+func (l *Link) Resolve(v Visitor) (string, *Rule) {
+	for i := range l.Rules {
+		if l.Rules[i].matches(v) {
+			return l.Rules[i].Destination, &l.Rules[i]
+		}
+	}
+	return l.LongURL, nil
+}
+
+/**** Helper Methods below ****/
+
+func loadRules(ctx context.Context, db *sql.DB, id uint64) ([]Rule, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT kind, value, destination FROM link_rules WHERE url_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var rule Rule
+		if err := rows.Scan(&rule.Kind, &rule.Value, &rule.Destination); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func insertRules(ctx context.Context, tx *sql.Tx, id uint64, rules []Rule) error {
+	for i, rule := range rules {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO link_rules(url_id, kind, value, destination, position) VALUES(?, ?, ?, ?, ?)",
+			id, rule.Kind, rule.Value, rule.Destination, i,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Per-link interstitial settings
+const (
+	InterstitialAlways = "always"
+	InterstitialNever  = "never" // trusted link, only trusted creators may set it
+)
+
+// Server-wide interstitial modes
+const (
+	InterstitialModeOff    = "off"
+	InterstitialModeAuto   = "auto"
+	InterstitialModeAlways = "always"
+)
+
+// ValidInterstitial reports whether v can be stored on a link
+func ValidInterstitial(v string) bool {
+	return v == "" || v == InterstitialAlways || v == InterstitialNever
+}
+
+// NeedsInterstitial reports whether visitors should see the safety page
+// before being sent on. In "auto" mode it's shown for suspicious links
+// and for links anonymous users created less than newFor ago.
+func (l *Link) NeedsInterstitial(mode string, newFor time.Duration, now time.Time) bool {
+	switch l.Interstitial {
+	case InterstitialAlways:
+		return true
+	case InterstitialNever:
+		return false
+	}
+	if l.CreatorTrusted {
+		return false
+	}
+
+	switch mode {
+	case InterstitialModeOff:
+		return false
+	case InterstitialModeAlways:
+		return true
+	}
+
+	if l.Suspicious {
+		return true
+	}
+	isNew := !l.CreatedAt.IsZero() && now.Sub(l.CreatedAt) < newFor
+	return l.CreatorID == 0 && isNew
+}
+
+// LooksSuspicious flags destinations commonly used to disguise where
+// a link really goes: raw IPs, credentials before the host
+// (https://bank.com@evil.example), punycode lookalike domains
+// and non-standard ports
+func LooksSuspicious(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	if u.User != nil {
+		return true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	for _, label := range strings.Split(host, ".") {
+		if strings.HasPrefix(label, "xn--") {
+			return true
+		}
+	}
+
+	port := u.Port()
+	return port != "" && port != "80" && port != "443"
+}
+
+// RedirectsOffsite reports whether fetching longURL ended up on another
+// site than the one it names, e.g. through a chain of redirectors
+func RedirectsOffsite(longURL, finalURL string) bool {
+	from, err := url.Parse(longURL)
+	if err != nil {
+		return false
+	}
+	to, err := url.Parse(finalURL)
+	if err != nil || to.Host == "" {
+		return false
+	}
+	return siteOf(from.Hostname()) != siteOf(to.Hostname())
+}
+
+// MarkSuspicious flags the link so that visitors get the interstitial
+func MarkSuspicious(ctx context.Context, db *sql.DB, id uint64) error {
+	_, err := db.ExecContext(ctx, "UPDATE urls SET suspicious = 1 WHERE id = ?", id)
+	return err
+}
+
+/**** Helper Methods below ****/
+
+// siteOf ignores the "www." prefix, so that example.com redirecting
+// to www.example.com isn't considered off-site
+func siteOf(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
@@ -0,0 +1,127 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"url-shortener/internal/utils"
+)
+
+// ExportRow is one exported link along with its stats and metadata
+type ExportRow struct {
+	Code          string     `json:"code"`
+	URL           string     `json:"url"`
+	CreatedAt     *time.Time `json:"created_at"`
+	ClickCount    int64      `json:"click_count"`
+	LastVisitedAt *time.Time `json:"last_visited_at"`
+	Title         string     `json:"title,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	ImageURL      string     `json:"image_url,omitempty"`
+}
+
+// Export calls fn with every link of the given creator, oldest first.
+// Rows are read one at a time, so exports of any size use little memory.
+func Export(ctx context.Context, db *sql.DB, creatorID int64, fn func(ExportRow) error) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT urls.id, link_codes.code, long_url, created_at, click_count, last_visited_at,
+		       link_metadata.title, link_metadata.description, link_metadata.image_url
+		FROM urls
+		LEFT JOIN link_codes ON link_codes.url_id = urls.id
+		LEFT JOIN link_metadata ON link_metadata.url_id = urls.id AND link_metadata.error IS NULL
+		WHERE urls.creator_id = ?
+		ORDER BY urls.id`, creatorID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row         ExportRow
+			id          uint64
+			code        sql.NullString
+			clickCount  sql.NullInt64
+			createdAt   sql.NullTime
+			lastVisited sql.NullTime
+			meta        [3]sql.NullString
+		)
+		err := rows.Scan(&id, &code, &row.URL, &createdAt, &clickCount, &lastVisited,
+			&meta[0], &meta[1], &meta[2])
+		if err != nil {
+			return err
+		}
+		row.Code = code.String
+		if !code.Valid {
+			row.Code = utils.Base62Encode(id)
+		}
+		row.CreatedAt = timePtr(createdAt)
+		row.ClickCount = clickCount.Int64
+		row.LastVisitedAt = timePtr(lastVisited)
+		row.Title, row.Description, row.ImageURL = meta[0].String, meta[1].String, meta[2].String
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Imported is a link recreated from an export, keeping its code and stats
+type Imported struct {
+	Link          *Link
+	DedupKey      string
+	Code          string
+	ClickCount    int64
+	LastVisitedAt *time.Time
+}
+
+// CodeOwner returns the destination of the link using code,
+// or an empty string if the code is free
+func CodeOwner(ctx context.Context, db *sql.DB, code string) (string, error) {
+	var longURL string
+	id, err := LookupCode(ctx, db, code)
+	if err == nil {
+		err = db.QueryRowContext(ctx, "SELECT long_url FROM urls WHERE id = ?", id).Scan(&longURL)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return longURL, err
+}
+
+// ImportMany inserts the links in a single transaction and sets their
+// IDs. Every code is kept in link_codes, issued ones included, so the
+// links' ids come from SQLite as usual. Either all links are imported
+// or none.
+func ImportMany(ctx context.Context, db *sql.DB, batch []*Imported) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids := make([]uint64, len(batch))
+	for i, imported := range batch {
+		if ids[i], err = insertLink(ctx, tx, imported.Link, imported.DedupKey); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE urls SET click_count = ?, last_visited_at = ? WHERE id = ?",
+			imported.ClickCount, nullTime(imported.LastVisitedAt), ids[i])
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO link_codes(code, url_id) VALUES(?, ?)", imported.Code, ids[i])
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i, imported := range batch {
+		imported.Link.ID = ids[i]
+	}
+	return nil
+}
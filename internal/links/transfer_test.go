@@ -0,0 +1,188 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"url-shortener/internal/db"
+)
+
+func openTestDB(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	conn := db.InitSQLite(filepath.Join(t.TempDir(), name))
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func exportAll(t *testing.T, conn *sql.DB, creatorID int64) []ExportRow {
+	t.Helper()
+	var rows []ExportRow
+	err := Export(context.Background(), conn, creatorID, func(row ExportRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+// importRows imports exported rows like the import endpoint does,
+// returning how many were already there and the codes that were reserved
+func importRows(t *testing.T, conn *sql.DB, creatorID int64, rows []ExportRow) (existing int, reserved []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	var batch []*Imported
+	for _, row := range rows {
+		if err := ValidateImportedCode(row.Code); err != nil {
+			t.Fatalf("code %q: %v", row.Code, err)
+		}
+		owner, err := CodeOwner(ctx, conn, row.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if owner == row.URL {
+			existing++
+			continue
+		}
+		if owner != "" {
+			t.Fatalf("code %q is used by %s", row.Code, owner)
+		}
+		isReserved, err := CodeReserved(ctx, conn, row.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isReserved {
+			reserved = append(reserved, row.Code)
+			continue
+		}
+		batch = append(batch, &Imported{
+			Link:          &Link{LongURL: row.URL, CreatorID: creatorID, CreatedAt: *row.CreatedAt},
+			DedupKey:      row.URL,
+			Code:          row.Code,
+			ClickCount:    row.ClickCount,
+			LastVisitedAt: row.LastVisitedAt,
+		})
+	}
+	if err := ImportMany(ctx, conn, batch); err != nil {
+		t.Fatal(err)
+	}
+	return existing, reserved
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := openTestDB(t, "source.db")
+
+	// A link created here, with an issued code...
+	native := &Link{LongURL: "https://example.com/native", CreatorID: 1}
+	if err := Create(ctx, source, native, native.LongURL); err != nil {
+		t.Fatal(err)
+	}
+	// ...and one imported from another shortener
+	lastVisited := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := ImportMany(ctx, source, []*Imported{{
+		Link:          &Link{LongURL: "https://example.com/imported", CreatorID: 1},
+		DedupKey:      "https://example.com/imported",
+		Code:          "old-code",
+		ClickCount:    42,
+		LastVisitedAt: &lastVisited,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exported := exportAll(t, source, 1)
+	if len(exported) != 2 || !IsIssuedCode(exported[0].Code) || exported[1].Code != "old-code" {
+		t.Fatalf("export = %+v, want the native link's issued code and the imported one's", exported)
+	}
+
+	// Into another database, codes from elsewhere keep pointing at their
+	// destination, but issued codes are left to the links created there
+	target := openTestDB(t, "target.db")
+	existing, reserved := importRows(t, target, 7, exported)
+	if existing != 0 || len(reserved) != 1 || reserved[0] != exported[0].Code {
+		t.Errorf("existing %d, reserved %v, want only %s reserved", existing, reserved, exported[0].Code)
+	}
+	if owner, err := CodeOwner(ctx, target, "old-code"); err != nil || owner != exported[1].URL {
+		t.Errorf("old-code points at %q (%v), want %s", owner, err, exported[1].URL)
+	}
+
+	// Importing into the source again finds every link already there
+	if existing, _ := importRows(t, source, 1, exported); existing != len(exported) {
+		t.Errorf("%d of %d links found again in the source", existing, len(exported))
+	}
+
+	// Once deleted, a native link can be restored with its issued code
+	if err := Delete(ctx, source, native.ID); err != nil {
+		t.Fatal(err)
+	}
+	if existing, reserved := importRows(t, source, 1, exported[:1]); existing != 0 || len(reserved) != 0 {
+		t.Fatalf("restore: existing %d, reserved %v, want it imported", existing, reserved)
+	}
+	id, err := LookupCode(ctx, source, exported[0].Code)
+	if err != nil || id == native.ID {
+		t.Fatalf("restored code resolves to %d (%v), want the new link", id, err)
+	}
+	reexported := exportAll(t, source, 1)
+	if len(reexported) != len(exported) {
+		t.Fatalf("re-export has %d rows, want %d", len(reexported), len(exported))
+	}
+	for _, got := range reexported {
+		want := exported[0]
+		if got.URL != want.URL {
+			want = exported[1]
+		}
+		if got.Code != want.Code || got.ClickCount != want.ClickCount || !got.CreatedAt.Equal(*want.CreatedAt) {
+			t.Errorf("re-exported %+v, want %+v", got, want)
+		}
+	}
+}
+
+// TestImportHighIssuedCode checks that importing an issued code far
+// ahead of the links created so far doesn't affect the next ones
+func TestImportHighIssuedCode(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t, "urls.db")
+
+	if reserved, err := CodeReserved(ctx, conn, "1"); err != nil || !reserved {
+		t.Errorf("CodeReserved(1) = %v, %v, want reserved", reserved, err)
+	}
+
+	// Even when imported anyway, the code doesn't move the id sequence
+	err := ImportMany(ctx, conn, []*Imported{{
+		Link:     &Link{LongURL: "https://example.com/high", CreatorID: 1},
+		DedupKey: "https://example.com/high",
+		Code:     "1",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := &Link{LongURL: "https://example.com/next", CreatorID: 1}
+	if err := Create(ctx, conn, link, link.LongURL); err != nil {
+		t.Fatal(err)
+	}
+
+	code := CodeOf(ctx, conn, link.ID)
+	if !IsIssuedCode(code) {
+		t.Fatalf("new link got id %d and code %q, want an issued code", link.ID, code)
+	}
+	if id, err := LookupCode(ctx, conn, code); err != nil || id != link.ID {
+		t.Errorf("LookupCode(%s) = %d, %v, want %d", code, id, err, link.ID)
+	}
+	if owner, err := CodeOwner(ctx, conn, "1"); err != nil || owner != "https://example.com/high" {
+		t.Errorf("code 1 points at %q (%v)", owner, err)
+	}
+}
+
+func TestCodeOwnerOfFreeIssuedCode(t *testing.T) {
+	conn := openTestDB(t, "urls.db")
+	owner, err := CodeOwner(context.Background(), conn, "6LAzd")
+	if err != nil || owner != "" {
+		t.Errorf("CodeOwner = %q, %v, want a free code", owner, err)
+	}
+}
@@ -0,0 +1,90 @@
+package links
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+)
+
+// Variant is one of several weighted destinations of a split link.
+// Visitors not matched by any rule are spread across variants in
+// proportion to their weights.
+type Variant struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+	Weight      int    `json:"weight"`
+}
+
+// VariantNamed returns the variant called name, if any. Used to keep
+// returning visitors on the variant they were first assigned to.
+func (l *Link) VariantNamed(name string) *Variant {
+	for i := range l.Variants {
+		if l.Variants[i].Name == name {
+			return &l.Variants[i]
+		}
+	}
+	return nil
+}
+
+// PickVariant assigns a variant at random, weighted by Weight
+func (l *Link) PickVariant() *Variant {
+	total := 0
+	for _, v := range l.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	n := rand.IntN(total)
+	for i := range l.Variants {
+		if n < l.Variants[i].Weight {
+			return &l.Variants[i]
+		}
+		n -= l.Variants[i].Weight
+	}
+	return nil
+}
+
+// VariantName returns the name of the i-th variant: "A", "B", ..., "Z", "AA", ...
+func VariantName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+/**** Helper Methods below ****/
+
+func loadVariants(ctx context.Context, db *sql.DB, id uint64) ([]Variant, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT name, destination, weight FROM link_variants WHERE url_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []Variant
+	for rows.Next() {
+		var v Variant
+		if err := rows.Scan(&v.Name, &v.Destination, &v.Weight); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+func insertVariants(ctx context.Context, tx *sql.Tx, id uint64, variants []Variant) error {
+	for i, v := range variants {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO link_variants(url_id, name, destination, weight, position) VALUES(?, ?, ?, ?, ?)",
+			id, v.Name, v.Destination, v.Weight, i,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package links
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// visitorDayTTL keeps the daily visitor sketches long enough
+// to be merged into the monthly figure
+const visitorDayTTL = 32 * 24 * time.Hour
+
+// UniqueVisitors are approximate counts of distinct visitors of a link,
+// over the last day, 7 days, 30 days and since it was created
+type UniqueVisitors struct {
+	Today   int64 `json:"today"`
+	Week    int64 `json:"week"`
+	Month   int64 `json:"month"`
+	AllTime int64 `json:"all_time"`
+}
+
+// VisitorsKey is the Redis key of the HyperLogLog of every
+// visitor of the link
+func VisitorsKey(id uint64) string {
+	return fmt.Sprintf("visitors:%d", id)
+}
+
+// VisitorsDayKey is the Redis key of the HyperLogLog of the link's
+// visitors on the given (UTC) day
+func VisitorsDayKey(id uint64, day time.Time) string {
+	return fmt.Sprintf("visitors:%d:%s", id, day.UTC().Format(time.DateOnly))
+}
+
+// Fingerprint identifies a visitor of a link without storing who they
+// are: an HMAC of their IP and user agent, keyed with the server's
+// secret and salted with the link, so visitors can't be followed from
+// one link to another.
+func Fingerprint(secret []byte, id uint64, ip, userAgent string) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d\x00%s\x00%s", id, ip, userAgent)
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// RecordVisitor adds the visitor's fingerprint to the link's sketches
+// of the day of the visit and of all time
+func RecordVisitor(ctx context.Context, rdb *redis.Client, id uint64, fingerprint string, at time.Time) error {
+	dayKey := VisitorsDayKey(id, at)
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.PFAdd(ctx, dayKey, fingerprint)
+		pipe.Expire(ctx, dayKey, visitorDayTTL)
+		pipe.PFAdd(ctx, VisitorsKey(id), fingerprint)
+		return nil
+	})
+	return err
+}
+
+// CountVisitors returns the link's unique visitors. The weekly and
+// monthly figures merge the daily sketches, so a visitor coming back
+// on another day is only counted once.
+func CountVisitors(ctx context.Context, rdb *redis.Client, id uint64, now time.Time) (UniqueVisitors, error) {
+	days := make([]string, 30)
+	for i := range days {
+		days[i] = VisitorsDayKey(id, now.AddDate(0, 0, -i))
+	}
+
+	pipe := rdb.Pipeline()
+	today := pipe.PFCount(ctx, days[0])
+	week := pipe.PFCount(ctx, days[:7]...)
+	month := pipe.PFCount(ctx, days...)
+	allTime := pipe.PFCount(ctx, VisitorsKey(id))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return UniqueVisitors{}, err
+	}
+	return UniqueVisitors{
+		Today:   today.Val(),
+		Week:    week.Val(),
+		Month:   month.Val(),
+		AllTime: allTime.Val(),
+	}, nil
+}
+
+// ForgetVisitors drops the link's visitor sketches
+func ForgetVisitors(ctx context.Context, rdb *redis.Client, id uint64, now time.Time) error {
+	keys := []string{VisitorsKey(id)}
+	for i := range 32 {
+		keys = append(keys, VisitorsDayKey(id, now.AddDate(0, 0, -i)))
+	}
+	return rdb.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,123 @@
+// Package live fans the click events published by the redirect handler
+// out to the streams following them. Each process holds one Redis
+// subscription, however many streams are open.
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/events"
+)
+
+// ErrTooManyStreams is returned by Subscribe when the client
+// already holds MaxPerIP streams
+var ErrTooManyStreams = errors.New("too many open streams")
+
+// streamBuffer is how many clicks a stream may fall behind by;
+// clicks beyond that are dropped for the slow stream only
+const streamBuffer = 64
+
+var (
+	Enabled bool
+
+	// MaxPerIP caps the streams a single client IP may hold at once
+	MaxPerIP int
+
+	mu      sync.Mutex
+	streams = make(map[*Stream]struct{})
+	perIP   = make(map[string]int)
+)
+
+// Stream receives the clicks published while it is open
+type Stream struct {
+	clicks chan events.ClickEvent
+	ip     string
+	once   sync.Once
+}
+
+// InitLive subscribes to click events and starts relaying them to
+// streams. The subscription reconnects by itself if Redis goes away.
+func InitLive(rdb *redis.Client, maxPerIP int) {
+	MaxPerIP = maxPerIP
+
+	sub := rdb.Subscribe(context.Background(), analytics.ClickChannel)
+
+	// Wait for the subscription so no click is missed once streams open
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := sub.Receive(ctx); err != nil {
+		log.Println("Click subscription failed, retrying in the background:", err)
+	}
+
+	go relay(sub.Channel())
+	Enabled = true
+}
+
+// Subscribe opens a stream for a client. Each client IP may hold at
+// most MaxPerIP streams; internal callers pass an empty ip to skip the
+// cap. The stream must be closed once the client hangs up.
+func Subscribe(ip string) (*Stream, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if ip != "" && perIP[ip] >= MaxPerIP {
+		return nil, ErrTooManyStreams
+	}
+	s := &Stream{clicks: make(chan events.ClickEvent, streamBuffer), ip: ip}
+	streams[s] = struct{}{}
+	if ip != "" {
+		perIP[ip]++
+	}
+	return s, nil
+}
+
+// Clicks returns the channel clicks are delivered on
+func (s *Stream) Clicks() <-chan events.ClickEvent {
+	return s.clicks
+}
+
+// Close stops the stream and frees its slot
+func (s *Stream) Close() {
+	s.once.Do(func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		delete(streams, s)
+		if s.ip != "" {
+			if perIP[s.ip]--; perIP[s.ip] <= 0 {
+				delete(perIP, s.ip)
+			}
+		}
+	})
+}
+
+/**** Helper Methods below ****/
+
+// relay decodes every click once and hands it to the open streams
+func relay(messages <-chan *redis.Message) {
+	for msg := range messages {
+		var event events.ClickEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Println("Invalid click event:", err)
+			continue
+		}
+
+		mu.Lock()
+		for s := range streams {
+			select {
+			case s.clicks <- event:
+			default:
+				// Stream too slow, it misses this click
+			}
+		}
+		mu.Unlock()
+	}
+}
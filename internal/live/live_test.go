@@ -0,0 +1,98 @@
+package live
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/events"
+)
+
+func TestSubscribeCapsStreamsPerIP(t *testing.T) {
+	MaxPerIP = 2
+
+	first, err := Subscribe("192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Subscribe("192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	if _, err := Subscribe("192.0.2.1"); !errors.Is(err, ErrTooManyStreams) {
+		t.Errorf("third stream: err = %v, want ErrTooManyStreams", err)
+	}
+	other, err := Subscribe("192.0.2.2")
+	if err != nil {
+		t.Errorf("other IP: %v", err)
+	}
+	defer other.Close()
+	for range 3 {
+		internal, err := Subscribe("")
+		if err != nil {
+			t.Errorf("uncapped stream: %v", err)
+		}
+		defer internal.Close()
+	}
+
+	// Closing a stream frees its slot, once
+	first.Close()
+	first.Close()
+	third, err := Subscribe("192.0.2.1")
+	if err != nil {
+		t.Fatalf("after closing one: %v", err)
+	}
+	defer third.Close()
+	if _, err := Subscribe("192.0.2.1"); !errors.Is(err, ErrTooManyStreams) {
+		t.Errorf("after closing one twice: err = %v, want ErrTooManyStreams", err)
+	}
+}
+
+func TestRelayFansOut(t *testing.T) {
+	MaxPerIP = 5
+	messages := make(chan *redis.Message)
+	go relay(messages)
+	defer close(messages)
+
+	a, _ := Subscribe("192.0.2.10")
+	defer a.Close()
+	b, _ := Subscribe("192.0.2.11")
+	closed, _ := Subscribe("192.0.2.12")
+	closed.Close()
+
+	payload, _ := json.Marshal(events.ClickEvent{ID: 42, Country: "DE"})
+	messages <- &redis.Message{Payload: "not json"}
+	messages <- &redis.Message{Payload: string(payload)}
+
+	for name, s := range map[string]*Stream{"a": a, "b": b} {
+		select {
+		case event := <-s.Clicks():
+			if event.ID != 42 || event.Country != "DE" {
+				t.Errorf("stream %s got %+v", name, event)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("stream %s got nothing", name)
+		}
+	}
+	select {
+	case event := <-closed.Clicks():
+		t.Errorf("closed stream got %+v", event)
+	default:
+	}
+
+	// A stream that doesn't keep up misses clicks instead of
+	// holding up the others
+	for range streamBuffer + 10 {
+		messages <- &redis.Message{Payload: string(payload)}
+		<-a.Clicks()
+	}
+	b.Close()
+	if n := len(b.clicks); n != streamBuffer {
+		t.Errorf("slow stream buffered %d clicks, want %d", n, streamBuffer)
+	}
+}
@@ -0,0 +1,212 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"url-shortener/internal/utils"
+)
+
+// Limits applied to every fetch
+const (
+	fetchTimeout = 10 * time.Second
+	maxBodyBytes = 1 << 20 // only the <head> is needed
+	maxRedirects = 5
+
+	maxTitleLen       = 300
+	maxDescriptionLen = 1000
+)
+
+// Metadata describes a link's destination, as used in previews
+type Metadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	StatusCode  int    `json:"status_code"`         // of the final response, after redirects
+	FinalURL    string `json:"final_url,omitempty"` // after redirects
+}
+
+// Fetcher retrieves destination metadata over HTTP
+type Fetcher struct {
+	Client *http.Client
+}
+
+// NewFetcher returns a Fetcher that refuses to connect to internal
+// addresses, including after redirects
+func NewFetcher() *Fetcher {
+	return newFetcher(utils.PublicDialer(5 * time.Second))
+}
+
+// Fetch requests rawURL and extracts its title, description and
+// OpenGraph image. Non-HTML responses only report the status code.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Go-MiniURL-Preview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	meta := &Metadata{
+		StatusCode: resp.StatusCode,
+		FinalURL:   resp.Request.URL.String(),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return meta, nil
+	}
+
+	parseHead(io.LimitReader(resp.Body, maxBodyBytes), meta)
+	meta.Title = truncate(meta.Title, maxTitleLen)
+	meta.Description = truncate(meta.Description, maxDescriptionLen)
+	meta.ImageURL = resolveURL(resp.Request.URL, meta.ImageURL)
+	return meta, nil
+}
+
+/**** Helper Methods below ****/
+
+// newFetcher returns a Fetcher connecting through dialer
+func newFetcher(dialer *net.Dialer) *Fetcher {
+	return &Fetcher{
+		Client: &http.Client{
+			Timeout: fetchTimeout,
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   5 * time.Second,
+				ResponseHeaderTimeout: 5 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return errors.New("unsupported redirect scheme")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// parseHead reads <title> and <meta> tags until the end of <head>.
+// OpenGraph values take precedence over the plain HTML ones.
+func parseHead(r io.Reader, meta *Metadata) {
+	var (
+		tokenizer = html.NewTokenizer(r)
+		inTitle   bool
+		title     string
+		ogTitle   string
+		desc      string
+		ogDesc    string
+	)
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// EOF or size limit reached
+			meta.Title = firstNonEmpty(ogTitle, title)
+			meta.Description = firstNonEmpty(ogDesc, desc)
+			return
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				tokenizer = html.NewTokenizer(strings.NewReader(""))
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				attrs := readAttrs(tokenizer)
+				key := strings.ToLower(firstNonEmpty(attrs["property"], attrs["name"]))
+				content := strings.TrimSpace(attrs["content"])
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "description":
+					desc = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if meta.ImageURL == "" {
+						meta.ImageURL = content
+					}
+				}
+			}
+
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				tokenizer = html.NewTokenizer(strings.NewReader(""))
+			}
+		}
+	}
+}
+
+func readAttrs(tokenizer *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, val, more := tokenizer.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// resolveURL makes a possibly relative image URL absolute,
+// dropping it unless it's http(s)
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
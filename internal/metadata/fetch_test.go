@@ -0,0 +1,150 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testFetcher returns a Fetcher allowed to reach httptest servers
+func testFetcher() *Fetcher {
+	return newFetcher(&net.Dialer{})
+}
+
+func TestFetchExtractsMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!doctype html><html><head>
+			<title> Plain title </title>
+			<meta name="description" content="Plain description">
+			<meta property="og:title" content="OpenGraph title">
+			<meta property="og:image" content="/cover.png">
+			</head><body><title>Not this one</title></body></html>`)
+	}))
+	defer srv.Close()
+
+	meta, err := testFetcher().Fetch(context.Background(), srv.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Metadata{
+		Title:       "OpenGraph title",
+		Description: "Plain description",
+		ImageURL:    srv.URL + "/cover.png",
+		StatusCode:  http.StatusOK,
+		FinalURL:    srv.URL + "/page",
+	}
+	if *meta != want {
+		t.Errorf("Fetch = %+v, want %+v", *meta, want)
+	}
+}
+
+func TestFetchSkipsNonHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"title": "<title>JSON</title>"}`)
+	}))
+	defer srv.Close()
+
+	meta, err := testFetcher().Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "" || meta.StatusCode != http.StatusOK {
+		t.Errorf("Fetch = %+v, want only the status code", *meta)
+	}
+}
+
+func TestFetchReadsAtMostMaxBodyBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head><!--")
+		fmt.Fprint(w, strings.Repeat("x", maxBodyBytes))
+		fmt.Fprint(w, "--><title>Too far</title></head></html>")
+	}))
+	defer srv.Close()
+
+	meta, err := testFetcher().Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "" {
+		t.Errorf("Title = %q, want nothing past the first %d bytes", meta.Title, maxBodyBytes)
+	}
+}
+
+func TestFetchTruncatesLongValues(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<title>%s</title>", strings.Repeat("é", 2*maxTitleLen))
+	}))
+	defer srv.Close()
+
+	meta, err := testFetcher().Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len([]rune(meta.Title)); n != maxTitleLen {
+		t.Errorf("title has %d runes, want %d", n, maxTitleLen)
+	}
+}
+
+func TestFetchFollowsRedirects(t *testing.T) {
+	// /hop/N redirects to /hop/N-1, and /hop/0 is the page
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+		if n > 0 {
+			http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<title>Arrived</title>")
+	}))
+	defer srv.Close()
+
+	meta, err := testFetcher().Fetch(context.Background(), srv.URL+"/hop/"+strconv.Itoa(maxRedirects))
+	if err != nil {
+		t.Fatalf("%d redirects: %v", maxRedirects, err)
+	}
+	if meta.Title != "Arrived" || meta.FinalURL != srv.URL+"/hop/0" {
+		t.Errorf("Fetch = %+v, want the final page", *meta)
+	}
+
+	_, err = testFetcher().Fetch(context.Background(), srv.URL+"/hop/"+strconv.Itoa(maxRedirects+1))
+	if err == nil || !strings.Contains(err.Error(), "too many redirects") {
+		t.Errorf("%d redirects: err = %v, want too many redirects", maxRedirects+1, err)
+	}
+}
+
+func TestFetchRefusesRedirectToOtherSchemes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	_, err := testFetcher().Fetch(context.Background(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "unsupported redirect scheme") {
+		t.Errorf("err = %v, want unsupported redirect scheme", err)
+	}
+}
+
+func TestFetchRefusesInternalAddresses(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+
+	_, err := NewFetcher().Fetch(context.Background(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "refusing to connect to internal address") {
+		t.Errorf("err = %v, want a refusal", err)
+	}
+	if hits != 0 {
+		t.Errorf("server got %d requests, want none", hits)
+	}
+}
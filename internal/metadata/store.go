@@ -0,0 +1,56 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Save stores the metadata fetched for a link, replacing any
+// previous fetch. fetchErr is recorded when the fetch failed.
+func Save(ctx context.Context, db *sql.DB, id uint64, meta *Metadata, fetchErr error) error {
+	if meta == nil {
+		meta = &Metadata{}
+	}
+	var errMsg sql.NullString
+	if fetchErr != nil {
+		errMsg = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO link_metadata (url_id, title, description, image_url, status_code, final_url, error, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (url_id) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			image_url = excluded.image_url,
+			status_code = excluded.status_code,
+			final_url = excluded.final_url,
+			error = excluded.error,
+			fetched_at = excluded.fetched_at`,
+		id, meta.Title, meta.Description, meta.ImageURL, meta.StatusCode, meta.FinalURL, errMsg,
+		time.Now().UTC(),
+	)
+	return err
+}
+
+// Get returns the metadata stored for a link, or nil if it
+// hasn't been fetched (successfully) yet
+func Get(ctx context.Context, db *sql.DB, id uint64) (*Metadata, error) {
+	var (
+		meta     Metadata
+		fetchErr sql.NullString
+	)
+	err := db.QueryRowContext(ctx, `
+		SELECT title, description, image_url, status_code, final_url, error
+		FROM link_metadata WHERE url_id = ?`, id).
+		Scan(&meta.Title, &meta.Description, &meta.ImageURL, &meta.StatusCode, &meta.FinalURL, &fetchErr)
+	if errors.Is(err, sql.ErrNoRows) || fetchErr.Valid {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
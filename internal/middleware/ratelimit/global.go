@@ -36,6 +36,7 @@ func Global(rdb *redis.Client, limit int64, window time.Duration) func(http.Hand
 			// Total requests exceeded the limit -> Return 429
 			if count > limit {
 				ttl, _ := rdb.TTL(ctx, key).Result()
+				setLimitHeaders(w, limit, ttl)
 				w.WriteHeader(http.StatusTooManyRequests)
 				w.Write([]byte("Global rate limit exceeded. Please try again after " + ttl.String()))
 				return
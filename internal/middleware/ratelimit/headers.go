@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// setLimitHeaders tells a rejected client when it may retry, so that
+// well-behaved clients back off instead of hammering the server
+func setLimitHeaders(w http.ResponseWriter, limit int64, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+	w.Header().Set("X-RateLimit-Remaining", "0")
+	// Unix time at which the client may retry
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+seconds, 10))
+}
+
+// slidingRetryAfter returns how long until the oldest request of a
+// sliding window leaves it, making room for a new one
+func slidingRetryAfter(ctx context.Context, rdb *redis.Client, key string, window time.Duration, now float64) time.Duration {
+	oldest, err := rdb.ZRangeWithScores(ctx, key, 0, 0).Result()
+	if err != nil || len(oldest) == 0 {
+		return window
+	}
+	leavesAt := oldest[0].Score + float64(window.Milliseconds())
+	return time.Duration(leavesAt-now) * time.Millisecond
+}
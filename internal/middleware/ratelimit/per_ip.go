@@ -36,9 +36,10 @@ func PerIP(rdb *redis.Client, limit int64, window time.Duration) func(http.Handl
 
 			// Total requests exceeded the limit -> Return 429
 			if count > limit {
-				ttl, _ := rdb.TTL(ctx, key).Result()
+				retryAfter := slidingRetryAfter(ctx, rdb, key, window, now)
+				setLimitHeaders(w, limit, retryAfter)
 				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte("Too many requests from your IP. Please try again after " + ttl.String()))
+				w.Write([]byte("Too many requests from your IP. Please try again after " + retryAfter.Round(time.Second).String()))
 				return
 			}
 
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortener/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PerIPAndPath returns a sliding-window rate limiting middleware
+// It enforces a request limit per (ip, path) pair, which makes it
+// suitable for brute-force protection of a single resource
+func PerIPAndPath(rdb *redis.Client, limit int64, window time.Duration) func(http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			// Key for this IP's rate bucket on this path
+			key := "rate:ip_path:" + utils.GetIP(r) + ":" + r.URL.Path
+
+			now := float64(time.Now().UnixMilli())
+			windowStart := now - float64(window.Milliseconds())
+			startScore := strconv.FormatFloat(windowStart, 'f', -1, 64)
+
+			// Remove timestamps older than current window
+			rdb.ZRemRangeByScore(ctx, key, "0", startScore)
+
+			endScore := strconv.FormatFloat(now, 'f', -1, 64)
+			count, _ := rdb.ZCount(ctx, key, startScore, endScore).Result()
+
+			// Attempts exceeded the limit -> Return 429
+			if count >= limit {
+				retryAfter := slidingRetryAfter(ctx, rdb, key, window, now)
+				setLimitHeaders(w, limit, retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte("Too many attempts. Please try again after " + retryAfter.Round(time.Second).String()))
+				return
+			}
+
+			// Add timestamp for current request
+			rdb.ZAdd(ctx, key, redis.Z{Score: now, Member: now})
+
+			// Set TTL with buffer so the key cannot expire mid-window
+			rdb.Expire(ctx, key, window*2)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
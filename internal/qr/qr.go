@@ -0,0 +1,176 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Supported output formats
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Size limits in pixels, to keep rendering cheap
+const (
+	MinSize = 64
+	MaxSize = 2048
+)
+
+// Options controls how a QR code is rendered
+type Options struct {
+	Format string
+	Size   int // width/height in pixels (approximate for PNG, see Render)
+	Margin int // quiet zone, in modules
+	Level  string
+	FG     color.RGBA
+	BG     color.RGBA
+}
+
+// DefaultOptions renders a black-on-white 256px PNG with the
+// standard 4-module quiet zone and medium error correction
+func DefaultOptions() Options {
+	return Options{
+		Format: FormatPNG,
+		Size:   256,
+		Margin: 4,
+		Level:  "M",
+		FG:     color.RGBA{0, 0, 0, 255},
+		BG:     color.RGBA{255, 255, 255, 255},
+	}
+}
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Validate checks that the options can be rendered
+func (o Options) Validate() error {
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return errors.New("Invalid format: must be png or svg")
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("Invalid size: must be between %d and %d", MinSize, MaxSize)
+	}
+	if o.Margin < 0 || o.Margin > 16 {
+		return errors.New("Invalid margin: must be between 0 and 16")
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return errors.New("Invalid error correction level: must be one of L, M, Q, H")
+	}
+	return nil
+}
+
+// ContentType returns the MIME type of the rendered image
+func (o Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Key uniquely identifies the rendering options, for caching
+func (o Options) Key() string {
+	return fmt.Sprintf("%s:%d:%d:%s:%s:%s", o.Format, o.Size, o.Margin, o.Level, Hex(o.FG), Hex(o.BG))
+}
+
+// Render encodes content as a QR code image. PNGs are scaled by a
+// whole number of pixels per module so they stay crisp, which can
+// make them slightly smaller than Size; SVGs are exactly Size.
+func Render(content string, opts Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, levels[opts.Level])
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	if opts.Format == FormatSVG {
+		return renderSVG(modules, opts), nil
+	}
+	return renderPNG(modules, opts)
+}
+
+// ParseHex parses an "RRGGBB" colour, with or without a leading '#'
+func ParseHex(s string) (color.RGBA, error) {
+	var c color.RGBA
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return c, errors.New("Invalid colour: must be RRGGBB")
+	}
+	if _, err := fmt.Sscanf(s, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, errors.New("Invalid colour: must be RRGGBB")
+	}
+	c.A = 255
+	return c, nil
+}
+
+// Hex formats c as "rrggbb"
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
+/**** Helper Methods below ****/
+
+func renderPNG(modules [][]bool, opts Options) ([]byte, error) {
+	total := len(modules) + 2*opts.Margin
+	scale := max(opts.Size/total, 1)
+	px := total * scale
+
+	// Two-colour palette keeps the file small
+	img := image.NewPaletted(image.Rect(0, 0, px, px), color.Palette{opts.BG, opts.FG})
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			x0, y0 := (x+opts.Margin)*scale, (y+opts.Margin)*scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(x0+dx, y0+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderSVG(modules [][]bool, opts Options) []byte {
+	total := len(modules) + 2*opts.Margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#%s"/>`, total, total, Hex(opts.BG))
+
+	// One path with a unit square per dark module
+	fmt.Fprintf(&buf, `<path fill="#%s" d="`, Hex(opts.FG))
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
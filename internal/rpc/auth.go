@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/auth"
+)
+
+// identifyUnary resolves the caller's API key like auth.Identify does
+// for HTTP requests. Calls without a key or with an unknown one are
+// rejected: the gRPC API isn't rate-limited, so it has no anonymous
+// callers.
+func identifyUnary(db *sql.DB) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := identify(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// identifyStream is identifyUnary for streaming calls
+func identifyStream(db *sql.DB) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := identify(ss.Context(), db)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identifiedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// identifiedStream carries the caller's API key in its context
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
+
+/**** Helper Methods below ****/
+
+func identify(ctx context.Context, db *sql.DB) (context.Context, error) {
+	raw := keyFromMetadata(ctx)
+	if raw == "" {
+		return nil, status.Error(codes.Unauthenticated, "API key required")
+	}
+	key, err := auth.Lookup(ctx, db, raw)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+	}
+	return auth.NewContext(ctx, key), nil
+}
+
+// keyFromMetadata returns the key sent as "x-api-key" or
+// "authorization: Bearer <key>" metadata, or ""
+func keyFromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		if key, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/links"
+	"url-shortener/internal/live"
+	pb "url-shortener/proto/miniurl/v1"
+)
+
+// StreamClicks relays the click events published by the redirect handler
+// until the caller hangs up, for one of the caller's links or all of them
+func (s *Server) StreamClicks(req *pb.StreamClicksRequest, stream grpc.ServerStreamingServer[pb.Click]) error {
+	ctx := stream.Context()
+	creator := auth.FromContext(ctx)
+
+	var id uint64
+	if req.GetCode() != "" {
+		var err error
+		if id, err = links.LookupCode(ctx, s.db, req.GetCode()); err != nil {
+			return status.Error(codes.NotFound, "Link not found!")
+		}
+		// Other keys' links look like they don't exist
+		link, err := links.Get(ctx, s.db, id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && link.CreatorID != creator.ID) {
+			return status.Error(codes.NotFound, "Link not found!")
+		} else if err != nil {
+			return status.Error(codes.Internal, "Database error")
+		}
+	}
+
+	if !live.Enabled {
+		return status.Error(codes.Unavailable, "Click stream unavailable")
+	}
+	// Callers have an API key, so their streams aren't capped per IP
+	clicks, err := live.Subscribe("")
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "Too many open streams")
+	}
+	defer clicks.Close()
+
+	// Codes of the links clicked so far
+	known := make(map[uint64]string)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-clicks.Clicks():
+			if event.Bot != "" || event.CreatorID != creator.ID || (id != 0 && event.ID != id) {
+				continue
+			}
+
+			code, ok := known[event.ID]
+			if !ok {
+				code = links.CodeOf(ctx, s.db, event.ID)
+				known[event.ID] = code
+			}
+			click := &pb.Click{
+				Code:    code,
+				Rule:    event.Rule,
+				Country: event.Country,
+				Variant: event.Variant,
+			}
+			if ts, err := time.Parse(time.RFC3339, event.TS); err == nil {
+				click.Time = timestamppb.New(ts)
+			}
+			if err := stream.Send(click); err != nil {
+				return err
+			}
+		}
+	}
+}
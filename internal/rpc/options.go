@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "url-shortener/proto/miniurl/v1"
+)
+
+// formValues encodes link options as the shorten form's fields,
+// so they go through the same validation as on the HTTP API
+func formValues(opts *pb.LinkOptions) url.Values {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+
+	set("url", opts.GetUrl())
+	if opts.GetRedirectType() != 0 {
+		set("redirect_type", strconv.Itoa(int(opts.GetRedirectType())))
+	}
+	if opts.GetMaxClicks() != 0 {
+		set("max_clicks", strconv.FormatInt(opts.GetMaxClicks(), 10))
+	}
+	if opts.GetNotBefore() != nil {
+		set("not_before", opts.GetNotBefore().AsTime().Format(time.RFC3339))
+	}
+	if opts.GetNotAfter() != nil {
+		set("not_after", opts.GetNotAfter().AsTime().Format(time.RFC3339))
+	}
+	set("fallback_url", opts.GetFallbackUrl())
+
+	for platform, destination := range opts.GetTargets() {
+		set("target_"+platform, destination)
+	}
+	var lines []string
+	for _, rule := range opts.GetGeoRules() {
+		lines = append(lines, rule.GetCountry()+" "+rule.GetUrl())
+	}
+	set("geo_rules", strings.Join(lines, "\n"))
+
+	lines = nil
+	for _, variant := range opts.GetVariants() {
+		lines = append(lines, strconv.Itoa(int(variant.GetWeight()))+" "+variant.GetUrl())
+	}
+	set("variants", strings.Join(lines, "\n"))
+
+	if opts.GetPassQuery() {
+		set("pass_query", "1")
+	}
+	if opts.GetPassPath() {
+		set("pass_path", "1")
+	}
+	set("password", opts.GetPassword())
+	set("interstitial", opts.GetInterstitial())
+
+	campaign := opts.GetCampaign()
+	set("utm_source", campaign.GetSource())
+	set("utm_medium", campaign.GetMedium())
+	set("utm_campaign", campaign.GetName())
+	set("utm_term", campaign.GetTerm())
+	set("utm_content", campaign.GetContent())
+	return v
+}
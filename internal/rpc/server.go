@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/config"
+	"url-shortener/internal/links"
+	"url-shortener/internal/web"
+	pb "url-shortener/proto/miniurl/v1"
+)
+
+// Server implements the gRPC API on top of the same logic as the
+// HTTP handlers, so links behave the same however they were created
+type Server struct {
+	pb.UnimplementedShortenerServer
+
+	db  *sql.DB
+	rdb *redis.Client
+	cfg *config.Config
+}
+
+// New returns a gRPC server with the Shortener service registered.
+// Every call requires an API key, which identifies the caller like on
+// the HTTP API.
+func New(db *sql.DB, rdb *redis.Client, cfg *config.Config) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(identifyUnary(db)),
+		grpc.StreamInterceptor(identifyStream(db)),
+	)
+	pb.RegisterShortenerServer(srv, &Server{db: db, rdb: rdb, cfg: cfg})
+	return srv
+}
+
+func (s *Server) CreateLink(ctx context.Context, req *pb.CreateLinkRequest) (*pb.CreateLinkResponse, error) {
+	results, err := web.ShortenAll(ctx, s.db, s.rdb, s.cfg, []url.Values{formValues(req.GetLink())})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Database error")
+	}
+	res := results[0]
+	if res.Err != nil {
+		return nil, status.Error(codes.InvalidArgument, res.Err.Error())
+	}
+	return &pb.CreateLinkResponse{
+		Code:     res.Code,
+		ShortUrl: s.shortURL(res.Code),
+		Existing: res.Existing,
+	}, nil
+}
+
+func (s *Server) BatchCreate(ctx context.Context, req *pb.BatchCreateRequest) (*pb.BatchCreateResponse, error) {
+	creator := auth.FromContext(ctx)
+	limit := s.cfg.BulkRowLimit
+	if creator.BulkRowLimit > 0 {
+		limit = creator.BulkRowLimit
+	}
+	if len(req.GetLinks()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "No links found")
+	}
+	if len(req.GetLinks()) > limit {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Too many links: the limit is %d", limit))
+	}
+
+	rows := make([]url.Values, len(req.GetLinks()))
+	for i, opts := range req.GetLinks() {
+		rows[i] = formValues(opts)
+	}
+	results, err := web.ShortenAll(ctx, s.db, s.rdb, s.cfg, rows)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Database error")
+	}
+
+	resp := &pb.BatchCreateResponse{Results: make([]*pb.BatchCreateResult, len(results))}
+	for i, res := range results {
+		result := &pb.BatchCreateResult{Index: int32(i), Existing: res.Existing}
+		if res.DuplicateOf > 0 {
+			result.DuplicateOf = proto.Int32(int32(res.DuplicateOf - 1))
+		}
+		if res.Err != nil {
+			result.Error = res.Err.Error()
+		} else {
+			result.Code = res.Code
+			result.ShortUrl = s.shortURL(res.Code)
+		}
+		resp.Results[i] = result
+	}
+	return resp, nil
+}
+
+func (s *Server) ResolveLink(ctx context.Context, req *pb.ResolveLinkRequest) (*pb.ResolveLinkResponse, error) {
+	link := web.LookupLink(ctx, s.db, s.rdb, req.GetCode())
+	if link == nil {
+		return nil, status.Error(codes.NotFound, "Link not found!")
+	}
+
+	// The destination of a protected link is only revealed after unlocking it
+	resp := &pb.ResolveLinkResponse{
+		Code:      req.GetCode(),
+		Protected: link.IsProtected(),
+		Disabled:  link.Disabled,
+	}
+	if !link.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(link.CreatedAt)
+	}
+	if !resp.Protected {
+		resp.LongUrl = link.LongURL
+		for _, rule := range link.Rules {
+			resp.Rules = append(resp.Rules, &pb.Rule{Kind: rule.Kind, Value: rule.Value, Destination: rule.Destination})
+		}
+		for _, variant := range link.Variants {
+			resp.Variants = append(resp.Variants, &pb.Variant{
+				Weight: int32(variant.Weight),
+				Url:    variant.Destination,
+				Name:   variant.Name,
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	id, err := links.LookupCode(ctx, s.db, req.GetCode())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "Link not found!")
+	}
+	totalClicks, lastVisited, err := links.ClickStats(ctx, s.db, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "Link not found!")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Database error")
+	}
+
+	botClicks, err := links.BotClicks(ctx, s.db, id)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Database error")
+	}
+	visitors, err := links.CountVisitors(ctx, s.rdb, id, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "Service temporarily unavailable")
+	}
+
+	resp := &pb.GetStatsResponse{
+		TotalClicks: totalClicks,
+		BotClicks:   botClicks,
+		UniqueVisitors: &pb.UniqueVisitors{
+			Today:   visitors.Today,
+			Week:    visitors.Week,
+			Month:   visitors.Month,
+			AllTime: visitors.AllTime,
+		},
+	}
+	if lastVisited != nil {
+		resp.LastVisitedAt = timestamppb.New(*lastVisited)
+	}
+	for _, dimension := range []string{"rule", "country", "variant"} {
+		breakdown, err := links.Breakdown(ctx, s.db, id, dimension)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Database error")
+		}
+		for _, row := range breakdown {
+			resp.Breakdown = append(resp.Breakdown, &pb.BreakdownRow{
+				Dimension: dimension,
+				Value:     row.Value,
+				Clicks:    int64(row.Clicks),
+			})
+		}
+	}
+	return resp, nil
+}
+
+/**** Helper Methods below ****/
+
+func (s *Server) shortURL(code string) string {
+	return s.cfg.PublicURL + "/" + code
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// NormalizeOptions toggles the optional, lossy normalisation steps
+type NormalizeOptions struct {
+	// StripTrackingParams drops utm_* and well-known click-id params
+	StripTrackingParams bool
+	// SortQuery orders query params by key so that ?a=1&b=2 == ?b=2&a=1
+	SortQuery bool
+}
+
+// trackingParams are removed when StripTrackingParams is set.
+// Any param starting with "utm_" is removed as well.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL returns a canonical form of rawURL, used as the
+// deduplication key for shortened links. It is never used as a
+// redirect target: links always redirect to the URL the user supplied.
+func NormalizeURL(rawURL string, opts NormalizeOptions) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", errors.New("URL has no host")
+	}
+
+	// Scheme and host are case-insensitive
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, port := u.Hostname(), u.Port()
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	// Internationalised domains -> punycode (bücher.de -> xn--bcher-kva.de)
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+
+	// Drop the port if it's the scheme's default
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// Bare IPv6 literal
+		host = "[" + host + "]"
+	}
+	u.Host = host
+
+	// "http://example.com" and "http://example.com/" are the same resource
+	path := normalizeEscapes(u.EscapedPath())
+	if path == "" {
+		path = "/"
+	}
+
+	query := normalizeQuery(u.RawQuery, opts)
+
+	var sb strings.Builder
+	sb.WriteString(u.Scheme + "://")
+	if u.User != nil {
+		sb.WriteString(u.User.String() + "@")
+	}
+	sb.WriteString(u.Host)
+	sb.WriteString(path)
+	if query != "" {
+		sb.WriteString("?" + query)
+	}
+	if u.Fragment != "" {
+		sb.WriteString("#" + u.EscapedFragment())
+	}
+	return sb.String(), nil
+}
+
+/**** Helper Methods below ****/
+
+// normalizeQuery normalises escapes in every param and optionally
+// strips tracking params and sorts the remaining ones by key
+func normalizeQuery(rawQuery string, opts NormalizeOptions) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		key string // decoded, for matching and sorting
+		raw string // normalised "key=value" as it will be written
+	}
+
+	var params []param
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		if opts.StripTrackingParams && isTrackingParam(key) {
+			continue
+		}
+		params = append(params, param{key: key, raw: normalizeEscapes(part)})
+	}
+
+	if opts.SortQuery {
+		// Stable so that repeated keys keep their relative order
+		sort.SliceStable(params, func(i, j int) bool {
+			return params[i].key < params[j].key
+		})
+	}
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.raw
+	}
+	return strings.Join(parts, "&")
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "utm_") || trackingParams[key]
+}
+
+// normalizeEscapes decodes percent-encoded unreserved characters
+// (RFC 3986 §2.3) and upper-cases the hex digits of all other escapes
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			b := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(b) {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('%')
+				sb.WriteString(strings.ToUpper(s[i+1 : i+3]))
+			}
+			i += 2
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func isUnreserved(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+		b == '-' || b == '.' || b == '_' || b == '~'
+}
+
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+func unhex(b byte) byte {
+	switch {
+	case '0' <= b && b <= '9':
+		return b - '0'
+	case 'a' <= b && b <= 'f':
+		return b - 'a' + 10
+	default:
+		return b - 'A' + 10
+	}
+}
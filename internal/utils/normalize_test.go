@@ -0,0 +1,92 @@
+package utils
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts NormalizeOptions
+		want string
+	}{
+		{name: "scheme and host case", in: "HTTP://Example.COM/Path", want: "http://example.com/Path"},
+		{name: "trailing dot in host", in: "http://example.com./a", want: "http://example.com/a"},
+		{name: "empty path", in: "http://example.com", want: "http://example.com/"},
+		{name: "default http port", in: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "default https port", in: "https://example.com:443/a", want: "https://example.com/a"},
+		{name: "other port kept", in: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "port of other scheme kept", in: "http://example.com:443/a", want: "http://example.com:443/a"},
+		{name: "IPv6 literal", in: "http://[::1]:80/a", want: "http://[::1]/a"},
+		{name: "IPv6 literal with port", in: "http://[::1]:8080/a", want: "http://[::1]:8080/a"},
+		{name: "internationalised domain", in: "https://bücher.de/", want: "https://xn--bcher-kva.de/"},
+		{name: "unreserved escapes decoded", in: "http://example.com/%7Euser/%41", want: "http://example.com/~user/A"},
+		{name: "reserved escapes upper-cased", in: "http://example.com/a%2fb?q=%3d", want: "http://example.com/a%2Fb?q=%3D"},
+		{name: "user info kept", in: "http://user:pw@example.com/", want: "http://user:pw@example.com/"},
+		{name: "fragment kept", in: "http://example.com/a#Top", want: "http://example.com/a#Top"},
+		{name: "empty query params dropped", in: "http://example.com/?a=1&&b=2", want: "http://example.com/?a=1&b=2"},
+		{name: "query order kept by default", in: "http://example.com/?b=2&a=1", want: "http://example.com/?b=2&a=1"},
+		{name: "tracking params kept by default", in: "http://example.com/?utm_source=x&a=1", want: "http://example.com/?utm_source=x&a=1"},
+		{
+			name: "query sorted",
+			in:   "http://example.com/?b=2&a=1&b=1",
+			opts: NormalizeOptions{SortQuery: true},
+			want: "http://example.com/?a=1&b=2&b=1",
+		},
+		{
+			name: "tracking params stripped",
+			in:   "http://example.com/?UTM_Source=x&a=1&fbclid=y&gclid=z",
+			opts: NormalizeOptions{StripTrackingParams: true},
+			want: "http://example.com/?a=1",
+		},
+		{
+			name: "only tracking params",
+			in:   "http://example.com/a?utm_medium=email",
+			opts: NormalizeOptions{StripTrackingParams: true},
+			want: "http://example.com/a",
+		},
+		{
+			name: "escaped tracking param name",
+			in:   "http://example.com/?utm%5Fcampaign=x&a=1",
+			opts: NormalizeOptions{StripTrackingParams: true},
+			want: "http://example.com/?a=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.in, tt.opts)
+			if err != nil {
+				t.Fatalf("NormalizeURL(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLErrors(t *testing.T) {
+	for _, in := range []string{"/relative/path", "http://%zz", "mailto:someone@example.com"} {
+		if got, err := NormalizeURL(in, NormalizeOptions{}); err == nil {
+			t.Errorf("NormalizeURL(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestNormalizeURLEquivalents(t *testing.T) {
+	opts := NormalizeOptions{StripTrackingParams: true, SortQuery: true}
+	same := []string{
+		"https://example.com/a?x=1&y=2",
+		"HTTPS://EXAMPLE.com:443/a?y=2&x=1",
+		"https://example.com/%61?utm_source=news&x=1&y=2",
+	}
+	want, err := NormalizeURL(same[0], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range same[1:] {
+		if got, err := NormalizeURL(in, opts); err != nil || got != want {
+			t.Errorf("NormalizeURL(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
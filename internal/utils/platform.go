@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Platforms recognised by DetectPlatform
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+	PlatformOther   = "other"
+)
+
+// DetectPlatform returns the visitor's operating system and whether
+// they're on a mobile device. User-Agent Client Hints are preferred
+// when the browser sends them, with the User-Agent string as fallback.
+func DetectPlatform(r *http.Request) (platform string, mobile bool) {
+	if hint := r.Header.Get("Sec-CH-UA-Platform"); hint != "" {
+		platform = platformFromHint(strings.Trim(hint, `" `))
+		mobile = r.Header.Get("Sec-CH-UA-Mobile") == "?1" ||
+			platform == PlatformIOS || platform == PlatformAndroid
+		return platform, mobile
+	}
+
+	ua := r.UserAgent()
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		return PlatformIOS, true
+	case strings.Contains(ua, "Android"):
+		return PlatformAndroid, true
+	case strings.Contains(ua, "Windows"):
+		platform = PlatformWindows
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		platform = PlatformMacOS
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		platform = PlatformLinux
+	default:
+		platform = PlatformOther
+	}
+	return platform, strings.Contains(ua, "Mobile")
+}
+
+func platformFromHint(hint string) string {
+	switch strings.ToLower(hint) {
+	case "ios":
+		return PlatformIOS
+	case "android":
+		return PlatformAndroid
+	case "windows":
+		return PlatformWindows
+	case "macos":
+		return PlatformMacOS
+	case "linux", "chrome os", "chromeos":
+		return PlatformLinux
+	}
+	return PlatformOther
+}
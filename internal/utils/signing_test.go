@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	secret := []byte("secret")
+	visitor := DeriveKey(secret, "visitor")
+
+	if !bytes.Equal(visitor, DeriveKey(secret, "visitor")) {
+		t.Error("DeriveKey isn't deterministic")
+	}
+	if bytes.Equal(visitor, secret) {
+		t.Error("derived key equals the secret")
+	}
+	if bytes.Equal(visitor, DeriveKey(secret, "other")) {
+		t.Error("labels don't separate keys")
+	}
+	if bytes.Equal(visitor, DeriveKey([]byte("other"), "visitor")) {
+		t.Error("secrets don't separate keys")
+	}
+}
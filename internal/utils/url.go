@@ -6,6 +6,8 @@ import (
 	"errors"
 	"net"
 	"net/url"
+	"syscall"
+	"time"
 )
 
 // ValidateLongURL checks whether a given URL is safe and valid
@@ -45,6 +47,33 @@ func HashURL(rawURL string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// IsPublicIP reports whether ip is safe for the server to connect to,
+// i.e. not loopback, private, link-local (e.g. cloud metadata
+// endpoints) or unspecified
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast()
+}
+
+// PublicDialer returns a dialer that refuses to connect to internal
+// addresses. The check runs on the IP actually dialled, so it also
+// covers redirects and DNS rebinding, unlike a lookup done up front.
+func PublicDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return errors.New("refusing to connect to internal address " + host)
+			}
+			return nil
+		},
+	}
+}
+
 /**** Helper Methods below ****/
 
 // isSafeURL performs basic safety and format validation to
@@ -56,7 +85,7 @@ func isSafeURL(rawURL string) bool {
 	}
 
 	// Resolve host to IP addresses
-	ips, err := net.LookupIP(u.Host)
+	ips, err := net.LookupIP(u.Hostname())
 	if err != nil {
 		return false
 	}
@@ -64,7 +93,7 @@ func isSafeURL(rawURL string) bool {
 	// Block internal, loopback, or private IP ranges
 	// Prevents SSRF(Server-Side Request Forgery) attacks
 	for _, ip := range ips {
-		if ip.IsLoopback() || ip.IsPrivate() {
+		if !IsPublicIP(ip) {
 			return false
 		}
 	}
@@ -0,0 +1,332 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/config"
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+// maxBulkBody bounds the size of a bulk upload
+const maxBulkBody = 16 << 20
+
+// bulkResult is the outcome of one row of a bulk upload. DuplicateOf
+// is the earlier row of the same upload whose link this row reuses.
+type bulkResult struct {
+	Row         int    `json:"row"`
+	URL         string `json:"url,omitempty"`
+	Code        string `json:"code,omitempty"`
+	ShortURL    string `json:"short_url,omitempty"`
+	Existing    bool   `json:"existing,omitempty"`
+	DuplicateOf int    `json:"duplicate_of,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// bulkSummary is the response to a bulk upload
+type bulkSummary struct {
+	Created    int          `json:"created"`
+	Existing   int          `json:"existing"`
+	Duplicates int          `json:"duplicates"`
+	Failed     int          `json:"failed"`
+	Results    []bulkResult `json:"results"`
+}
+
+// LinkResult is the outcome of shortening one row of link options
+type LinkResult struct {
+	Code     string
+	Existing bool
+
+	// DuplicateOf is the 1-based row of an earlier row repeating the
+	// same plain link, whose new link this row shares; 0 if none
+	DuplicateOf int
+
+	// Err is why the row was rejected, meant for the caller
+	Err error
+}
+
+// ShortenBulk shortens every row of a JSON array or CSV upload. Rows take
+// the same fields as the shorten form and go through the same validation
+// and deduplication; new links are inserted in a single transaction.
+func ShortenBulk(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.Client, cfg *config.Config) {
+	ctx := r.Context()
+
+	rows, ok := readBulkRows(w, r, cfg)
+	if !ok {
+		// Error response already written
+		return
+	}
+
+	linkResults, err := ShortenAll(ctx, db, rdb, cfg, rows)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]bulkResult, len(rows))
+	for i, res := range linkResults {
+		results[i] = bulkResult{Row: i + 1, URL: rows[i].Get("url"), Existing: res.Existing, DuplicateOf: res.DuplicateOf}
+		if res.Err != nil {
+			results[i].Error = res.Err.Error()
+			continue
+		}
+		results[i].Code = res.Code
+		results[i].ShortURL = shortURLFor(r, res.Code)
+	}
+	writeBulkResults(w, results)
+}
+
+// ShortenAll shortens every row of shorten form fields the way ShortenURL
+// does, on behalf of the API key in ctx (if any). New links are inserted
+// in a single transaction; an error is only returned if it fails.
+func ShortenAll(ctx context.Context, db *sql.DB, rdb *redis.Client, cfg *config.Config, rows []url.Values) ([]LinkResult, error) {
+	base, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results = make([]LinkResult, len(rows))
+		batch   []*links.Link
+		keys    []string
+
+		// Result row -> index in batch, -1 if not created by this call
+		created = make([]int, len(rows))
+		// Dedup key -> first row creating it, so that a plain link
+		// repeated within the rows is only created once
+		pending = make(map[string]int)
+	)
+	for i, values := range rows {
+		result := &results[i]
+		created[i] = -1
+
+		link, err := validateShortenRequest(rowRequest(base, values))
+		if err != nil {
+			result.Err = err
+			continue
+		}
+		dedupKey, err := utils.NormalizeURL(link.LongURL, cfg.Normalize)
+		if err != nil {
+			result.Err = errors.New("Invalid or unsafe URL")
+			continue
+		}
+
+		// Links with options are never deduplicated
+		if link.IsPlain() {
+			if first, ok := pending[dedupKey]; ok {
+				created[i], result.DuplicateOf = created[first], first+1
+				continue
+			}
+			code, err := findPlainLink(ctx, db, rdb, link, dedupKey)
+			if err != nil {
+				result.Err = errors.New("Database error")
+				continue
+			}
+			if code != "" {
+				result.Code, result.Existing = code, true
+				continue
+			}
+			pending[dedupKey] = i
+		}
+		created[i] = len(batch)
+		batch = append(batch, link)
+		keys = append(keys, dedupKey)
+	}
+
+	// Insert all new links at once
+	if len(batch) > 0 {
+		if err := links.CreateMany(ctx, db, batch, keys); err != nil {
+			return nil, err
+		}
+		for i, link := range batch {
+			announceLink(ctx, rdb, utils.Base62Encode(link.ID), link, keys[i])
+		}
+	}
+
+	for i := range results {
+		if j := created[i]; j >= 0 {
+			results[i].Code = utils.Base62Encode(batch[j].ID)
+		}
+	}
+	return results, nil
+}
+
+/**** Helper Methods below ****/
+
+// readBulkRows reads the rows of a bulk upload, enforcing the caller's
+// row limit. An API key is required.
+func readBulkRows(w http.ResponseWriter, r *http.Request, cfg *config.Config) ([]url.Values, bool) {
+	creator := auth.FromContext(r.Context())
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return nil, false
+	}
+	limit := cfg.BulkRowLimit
+	if creator.BulkRowLimit > 0 {
+		limit = creator.BulkRowLimit
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBody)
+	rows, err := parseBulkRows(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if len(rows) == 0 {
+		http.Error(w, "No rows found", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(rows) > limit {
+		http.Error(w, fmt.Sprintf("Too many rows: the limit is %d", limit), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return rows, true
+}
+
+// writeBulkResults writes the per-row results of a bulk upload
+// along with how many links were created, reused, repeated or rejected
+func writeBulkResults(w http.ResponseWriter, results []bulkResult) {
+	var summary bulkSummary
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			summary.Failed++
+		case result.Existing:
+			summary.Existing++
+		case result.DuplicateOf > 0:
+			summary.Duplicates++
+		default:
+			summary.Created++
+		}
+	}
+	summary.Results = results
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// parseBulkRows reads the rows of a JSON array of objects, NDJSON (one
+// object per line), a CSV body or a CSV file uploaded as "file". CSV
+// files start with a header naming the fields of each column.
+func parseBulkRows(r *http.Request) ([]url.Values, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/json":
+		return parseJSONRows(r.Body)
+	case "application/x-ndjson":
+		return parseNDJSONRows(r.Body)
+	case "text/csv":
+		return parseCSVRows(r.Body)
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("CSV file required")
+		}
+		defer file.Close()
+		return parseCSVRows(file)
+	}
+	return nil, errors.New("Unsupported content type: use application/json, application/x-ndjson, text/csv or multipart/form-data")
+}
+
+func parseJSONRows(body io.Reader) ([]url.Values, error) {
+	var objects []map[string]any
+	if err := json.NewDecoder(body).Decode(&objects); err != nil {
+		return nil, errors.New("Invalid JSON: expected an array of objects")
+	}
+	return objectRows(objects)
+}
+
+func parseNDJSONRows(body io.Reader) ([]url.Values, error) {
+	var objects []map[string]any
+	decoder := json.NewDecoder(body)
+	for {
+		var object map[string]any
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid NDJSON on row %d: expected one object per line", len(objects)+1)
+		}
+		objects = append(objects, object)
+	}
+	return objectRows(objects)
+}
+
+// objectRows turns JSON objects into form values,
+// e.g. {"max_clicks": 5} -> max_clicks=5
+func objectRows(objects []map[string]any) ([]url.Values, error) {
+	rows := make([]url.Values, len(objects))
+	for i, object := range objects {
+		rows[i] = url.Values{}
+		for field, value := range object {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				rows[i].Set(field, v)
+			case float64:
+				rows[i].Set(field, strconv.FormatFloat(v, 'f', -1, 64))
+			case bool:
+				// Like an unchecked checkbox, false fields aren't sent
+				if v {
+					rows[i].Set(field, "true")
+				}
+			default:
+				return nil, fmt.Errorf("Row %d: %s must be a string, number or boolean", i+1, field)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func parseCSVRows(body io.Reader) ([]url.Values, error) {
+	records, err := csv.NewReader(body).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	rows := make([]url.Values, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := url.Values{}
+		for i, value := range record {
+			if value != "" {
+				row.Set(header[i], value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// rowRequest makes a bulk row look like a shorten form post, so that
+// it goes through exactly the same validation as ShortenURL
+func rowRequest(r *http.Request, values url.Values) *http.Request {
+	row := r.Clone(r.Context())
+	row.Body = http.NoBody
+	row.Form = values
+	row.PostForm = values
+	row.MultipartForm = &multipart.Form{}
+	return row
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/bloom"
+	"url-shortener/internal/config"
+	"url-shortener/internal/db"
+)
+
+func TestShortenAllRepeatedURL(t *testing.T) {
+	ctx := context.Background()
+	conn := db.InitSQLite(filepath.Join(t.TempDir(), "urls.db"))
+	t.Cleanup(func() { conn.Close() })
+	bloom.InitBloom(1000, 0.01)
+	if err := bloom.Populate(conn); err != nil {
+		t.Fatal(err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	cfg := &config.Config{}
+
+	// IP literals, so that validating them needs no DNS
+	rows := []url.Values{
+		{"url": {"https://93.184.216.34/a"}},
+		{"url": {"https://93.184.216.34/b"}},
+		{"url": {"https://93.184.216.34/a"}},
+		{"url": {"HTTPS://93.184.216.34/a"}},
+		{"url": {"https://93.184.216.34/a"}, "max_clicks": {"1"}},
+	}
+	results, err := ShortenAll(ctx, conn, rdb, cfg, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if res.Err != nil || res.Existing {
+			t.Errorf("row %d: %+v, want a link created by this call", i+1, res)
+		}
+	}
+	for _, i := range []int{2, 3} {
+		if results[i].DuplicateOf != 1 || results[i].Code != results[0].Code {
+			t.Errorf("row %d: %+v, want a duplicate of row 1 (%s)", i+1, results[i], results[0].Code)
+		}
+	}
+	if results[1].DuplicateOf != 0 || results[4].DuplicateOf != 0 || results[4].Code == results[0].Code {
+		t.Errorf("rows 2 and 5 = %+v, %+v, want links of their own", results[1], results[4])
+	}
+
+	// The repeats count neither as created nor as existing
+	bulk := make([]bulkResult, len(results))
+	for i, res := range results {
+		bulk[i] = bulkResult{Row: i + 1, Code: res.Code, Existing: res.Existing, DuplicateOf: res.DuplicateOf}
+	}
+	w := httptest.NewRecorder()
+	writeBulkResults(w, bulk)
+	var summary bulkSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Created != 3 || summary.Existing != 0 || summary.Duplicates != 2 || summary.Failed != 0 {
+		t.Errorf("summary = %+v, want 3 created and 2 duplicates", summary)
+	}
+
+	// In a later upload, the link exists
+	again, err := ShortenAll(ctx, conn, rdb, cfg, rows[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again[0].Existing || again[0].DuplicateOf != 0 || again[0].Code != results[0].Code {
+		t.Errorf("later upload: %+v, want the existing %s", again[0], results[0].Code)
+	}
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/links"
+	"url-shortener/internal/web/ui"
+)
+
+// CampaignStats renders click totals of the caller's campaign links,
+// grouped by campaign, source or medium and optionally filtered by any
+// of them
+func CampaignStats(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	creator := auth.FromContext(ctx)
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	filter := links.Campaign{
+		Source: strings.TrimSpace(query.Get("source")),
+		Medium: strings.TrimSpace(query.Get("medium")),
+		Name:   strings.TrimSpace(query.Get("campaign")),
+	}
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = "campaign"
+	}
+	if _, ok := links.CampaignGroupings[groupBy]; !ok {
+		http.Error(w, "Invalid grouping: must be one of campaign, source, medium", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := links.CampaignStats(ctx, db, creator.ID, filter, groupBy)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if wantsJSON(r) {
+		if stats == nil {
+			stats = []links.CampaignStat{}
+		}
+		writeJSON(w, http.StatusOK, stats)
+		return
+	}
+
+	// Write Response
+	data := struct {
+		Filter  links.Campaign
+		GroupBy string
+		Stats   []links.CampaignStat
+	}{filter, groupBy, stats}
+	ui.Render(w, http.StatusOK, "campaign-stats.html", data)
+}
+
+/**** Helper Methods below ****/
+
+// parseCampaign reads the optional utm_* fields and adds them to
+// the link's destination, keeping them as metadata for stats
+func parseCampaign(r *http.Request, link *links.Link) error {
+	campaign := links.Campaign{
+		Source:  strings.TrimSpace(r.FormValue("utm_source")),
+		Medium:  strings.TrimSpace(r.FormValue("utm_medium")),
+		Name:    strings.TrimSpace(r.FormValue("utm_campaign")),
+		Term:    strings.TrimSpace(r.FormValue("utm_term")),
+		Content: strings.TrimSpace(r.FormValue("utm_content")),
+	}
+	if campaign.IsZero() {
+		return nil
+	}
+	if err := campaign.Validate(); err != nil {
+		return err
+	}
+
+	longURL, err := campaign.Apply(link.LongURL)
+	if err != nil {
+		return errors.New("Invalid URL format")
+	}
+	link.LongURL = longURL
+	link.Campaign = campaign
+	return nil
+}
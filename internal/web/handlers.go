@@ -3,6 +3,7 @@ package web
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -14,73 +15,162 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"url-shortener/internal/bloom"
+	"url-shortener/internal/bots"
+	"url-shortener/internal/config"
+	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+	"url-shortener/internal/metadata"
 	"url-shortener/internal/utils"
+	"url-shortener/internal/web/ui"
 )
 
-func ShortenURL(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.Client) {
+func ShortenURL(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.Client, cfg *config.Config) {
 	ctx := r.Context()
-	var id int64
 
-	// Validate request and get long URL
-	longURL, err := validateShortenRequest(r)
+	// Validate request and get the link to create
+	link, err := validateShortenRequest(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if bloom.MightExist(longURL) {
-		// Try Redis
-		longKey := "long_to_id:" + utils.HashURL(longURL)
-		if cachedID, err := rdb.Get(ctx, longKey).Result(); err == nil {
-			id, _ = strconv.ParseInt(cachedID, 10, 64)
-			code := utils.Base62Encode(uint64(id))
-			writeShortURL(w, r, code)
-			return
-		}
+	// Equivalent spellings of a URL share one dedup key,
+	// but the link still redirects to the URL as supplied
+	dedupKey, err := utils.NormalizeURL(link.LongURL, cfg.Normalize)
+	if err != nil {
+		http.Error(w, "Invalid or unsafe URL", http.StatusBadRequest)
+		return
+	}
 
-		// Redis miss -> Try SQLite
-		err := db.QueryRow("SELECT id FROM urls WHERE long_url = ?", longURL).Scan(&id)
-		if err == nil {
-			code := utils.Base62Encode(uint64(id))
-			storeShortAndLongKeysInRedis(ctx, rdb, code, longURL, id)
-			writeShortURL(w, r, code)
+	// Links with options are never deduplicated
+	if link.IsPlain() {
+		code, err := findPlainLink(ctx, db, rdb, link, dedupKey)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
-		if !errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Database error", http.StatusInternalServerError)
+		if code != "" {
+			writeShortURL(w, r, code)
 			return
 		}
 	}
 
-	// Definitely a NEW URL -> Insert in DB
-	res, err := db.Exec("INSERT INTO urls(long_url) VALUES(?)", longURL)
-	if err != nil {
+	// Definitely a NEW link -> Insert in DB
+	if err := links.Create(ctx, db, link, dedupKey); err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
-
-	id, _ = res.LastInsertId()
-	code := utils.Base62Encode(uint64(id))
-
-	// Store in Bloom and Redis
-	bloom.Add(longURL)
-	storeShortAndLongKeysInRedis(ctx, rdb, code, longURL, id)
+	code := utils.Base62Encode(link.ID)
+	announceLink(ctx, rdb, code, link, dedupKey)
 	writeShortURL(w, r, code)
 }
 
-func RedirectURL(w http.ResponseWriter, r *http.Request, code string, db *sql.DB, rdb *redis.Client) {
+// RedirectURL sends the visitor to the destination of the link with
+// the given code. extraPath is whatever followed "/{code}/" in the
+// request, and is only accepted by links with path passthrough.
+func RedirectURL(w http.ResponseWriter, r *http.Request, code string, extraPath string, db *sql.DB, rdb *redis.Client, cfg *config.Config) {
 	ctx := r.Context()
 
-	id, longURL := retrieveLongURL(ctx, db, rdb, code)
-	if longURL == "" {
+	link := retrieveLink(ctx, db, rdb, code)
+	if link == nil || (extraPath != "" && !link.PassPath) {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	}
+	if link.Disabled {
+		http.Error(w, "This link has been disabled", http.StatusGone)
+		return
+	}
+
+	// Scheduled link -> Checked on every visit, so a cached
+	// link is never served outside of its activation window
+	if now := time.Now(); !link.ActiveAt(now) {
+		serveInactiveLink(w, r, link, now)
+		return
+	}
+
+	// Protected link -> Ask for the password unless already unlocked
+	if link.IsProtected() && !isUnlocked(r, code, link, cfg) {
+		renderPasswordPrompt(w, code, http.StatusOK, "")
+		return
+	}
+
+	// Bots are still redirected, but their clicks are counted apart
+	bot := bots.Classify(r)
+
+	// Click-limited link -> Count the click before redirecting. Bots are
+	// redirected while clicks are left, but don't spend any, so unfurlers
+	// can't use up a link before anyone opens it.
+	consumeClick := links.ConsumeClick
+	if bot != "" {
+		consumeClick = links.CheckClick
+	}
+	if err := consumeClick(ctx, db, rdb, link); err != nil {
+		if errors.Is(err, links.ErrBudgetSpent) {
+			http.Error(w, "This link has expired", http.StatusGone)
+			return
+		}
+		// Fail closed: a one-time link must never be let through unchecked
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Targeted link -> Pick the destination for this visitor
+	visitor := visitorFrom(r)
+	destination, rule := link.Resolve(visitor)
+
+	// Split link -> Visitors matching no rule get their (sticky) variant
+	var variant *links.Variant
+	if rule == nil && len(link.Variants) > 0 {
+		if variant = assignVariant(w, r, code, link); variant != nil {
+			destination = variant.Destination
+		}
+	}
+
+	// Passthrough link -> Forward the visitor's extra path and query
+	destination, err := applyPassthrough(destination, link, extraPath, r.URL.RawQuery)
+	if err != nil {
 		http.Error(w, "Link not found!", http.StatusNotFound)
 		return
 	}
 
 	// Publish click event
-	analytics.PublishClickEvent(rdb, id)
+	event := events.ClickEvent{
+		ID:        link.ID,
+		Country:   visitor.Country,
+		CreatorID: link.CreatorID,
+		Bot:       bot,
+	}
+	if bot == "" {
+		event.Visitor = links.Fingerprint(cfg.VisitorSecret, link.ID, utils.GetIP(r), r.UserAgent())
+	}
+	if variant != nil {
+		event.Variant = variant.Name
+	}
+	if len(link.Rules) > 0 {
+		event.Rule = "default"
+		if rule != nil {
+			event.Rule = rule.Name()
+		}
+		w.Header().Set("Vary", "User-Agent, Sec-CH-UA-Platform, Sec-CH-UA-Mobile")
+	}
+	analytics.PublishClickEvent(rdb, event)
 
-	http.Redirect(w, r, longURL, http.StatusFound)
+	// Unvetted link -> Show where it leads and let the visitor continue
+	if link.NeedsInterstitial(cfg.InterstitialMode, cfg.InterstitialNewFor, time.Now()) {
+		renderInterstitial(w, ctx, db, link, destination)
+		return
+	}
+
+	status := link.RedirectType
+	if status == 0 {
+		status = cfg.DefaultRedirectStatus
+	}
+	// A cached redirect would bypass the click budget, schedule or password
+	if link.IsVolatile() && links.IsPermanentRedirect(status) {
+		status = http.StatusFound
+	}
+	setRedirectCacheControl(w, status)
+	http.Redirect(w, r, destination, status)
 }
 
 func PreviewURL(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.Client) {
@@ -92,25 +182,35 @@ func PreviewURL(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.C
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	_, longURL := retrieveLongURL(ctx, db, rdb, code)
-	if longURL == "" {
+	link := retrieveLink(ctx, db, rdb, code)
+	if link == nil {
 		http.Error(w, "Link not found!", http.StatusNotFound)
 		return
 	}
 
 	// Write Response
-	htmlSnippet := fmt.Sprintf(`
-		<div class="p-4 bg-green-100 text-green-700 rounded">
-			<p class="mb-1 font-bold">Original URL:</p>
-			<a href="%s" target="_blank" class="underline font-medium">%s</a>
-		</div>
-	`, longURL, longURL)
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(htmlSnippet))
+	// The destination of a protected link is only revealed after unlocking it
+	data := struct {
+		LongURL   string
+		Rules     []links.Rule
+		Variants  []links.Variant
+		Meta      *metadata.Metadata
+		Protected bool
+	}{}
+	if link.IsProtected() {
+		data.Protected = true
+	} else {
+		data.LongURL = link.LongURL
+		data.Rules = link.Rules
+		data.Variants = link.Variants
+
+		// Missing until the worker has fetched the destination
+		data.Meta, _ = metadata.Get(ctx, db, link.ID)
+	}
+	ui.Render(w, http.StatusOK, "preview-result.html", data)
 }
 
-func TrackClicks(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+func TrackClicks(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.Client) {
 	ctx := r.Context()
 
 	// Validate request and get short code
@@ -119,45 +219,147 @@ func TrackClicks(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	id := utils.Base62Decode(code)
+	id, err := links.LookupCode(ctx, db, code)
+	if err != nil {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	}
 	totalClicks, lastVisited := retrieveClickStats(w, ctx, db, id)
+	if lastVisited == "" {
+		// Error response already written
+		return
+	}
+
+	variants, err := links.Breakdown(ctx, db, id, "variant")
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	botClicks, err := links.BotClicks(ctx, db, id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	visitors, err := links.CountVisitors(ctx, rdb, id, time.Now())
+	if err != nil {
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
 
 	// Write Response
-	htmlSnippet := fmt.Sprintf(`
-		<div class="space-y-2 p-4 px-4 sm:px-6 md:px-8 bg-green-100 text-green-700 rounded">
-			<div class="flex items-center gap-1">
-				<span class="flex items-center gap-2 text-gray-600 font-semibold w-32 shrink-0">
-					<i data-lucide="bar-chart-2" class="w-4 h-4"></i>Total Clicks
-				</span>
-				<span class="font-semibold">%d</span>
-			</div>
-			<div class="flex items-center gap-1">
-				<span class="flex items-center gap-2 text-gray-600 font-semibold w-32 shrink-0">
-					<i data-lucide="clock" class="w-4 h-4"></i>Last Visited
-				</span>
-				<span id="last-visited" data-utc="%s" class="font-semibold">—</span>
-			</div>
-		</div>
-	`, totalClicks, lastVisited)
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(htmlSnippet))
+	data := struct {
+		Code           string
+		TotalClicks    int
+		BotClicks      int64
+		UniqueVisitors links.UniqueVisitors
+		LastVisited    string
+		Variants       []links.BreakdownRow
+	}{code, totalClicks, botClicks, visitors, lastVisited, variants}
+	ui.Render(w, http.StatusOK, "click-stats.html", data)
+}
+
+// LookupLink returns the link with the given code, from Redis if cached,
+// or nil if there is none
+func LookupLink(ctx context.Context, db *sql.DB, rdb *redis.Client, code string) *links.Link {
+	return retrieveLink(ctx, db, rdb, code)
 }
 
 /**** Helper Methods below ****/
 
-func validateShortenRequest(r *http.Request) (string, error) {
+// parseFlag reads an optional boolean field like "1", "true" or "false";
+// empty means false, like an unchecked checkbox
+func parseFlag(r *http.Request, name string) (bool, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return false, nil
+	}
+	flag, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s: must be true or false", name)
+	}
+	return flag, nil
+}
+
+func validateShortenRequest(r *http.Request) (*links.Link, error) {
 	// Parse URL
 	url, err := ParseAndGetURL(r)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// Validate URL
 	url, err = utils.ValidateLongURL(url)
 	if err != nil {
-		return url, err
+		return nil, err
 	}
-	return url, nil
+
+	link := &links.Link{LongURL: url}
+
+	// Optional UTM campaign; its params are added to the destination
+	if err := parseCampaign(r, link); err != nil {
+		return nil, err
+	}
+
+	// Optional redirect type; empty -> server default
+	if v := r.FormValue("redirect_type"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil || !links.ValidRedirectType(status) {
+			return nil, errors.New("Invalid redirect type: must be one of 301, 302, 307, 308")
+		}
+		link.RedirectType = status
+	}
+
+	// Optional click budget; the link expires after this many clicks
+	if v := r.FormValue("max_clicks"); v != "" {
+		maxClicks, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || maxClicks < 1 {
+			return nil, errors.New("Max clicks must be a positive number")
+		}
+		link.MaxClicks = maxClicks
+	}
+
+	// Optional activation window
+	if err := parseSchedule(r, link); err != nil {
+		return nil, err
+	}
+
+	// Optional per-country and per-platform destinations
+	if err := parseTargeting(r, link); err != nil {
+		return nil, err
+	}
+
+	// Optional passthrough of the visitor's query string and extra path
+	if link.PassQuery, err = parseFlag(r, "pass_query"); err != nil {
+		return nil, err
+	}
+	if link.PassPath, err = parseFlag(r, "pass_path"); err != nil {
+		return nil, err
+	}
+
+	// Optional A/B split across weighted destinations
+	if err := parseVariants(r, link); err != nil {
+		return nil, err
+	}
+
+	// Optional safety interstitial; also records the link's creator
+	if err := parseInterstitial(r, link); err != nil {
+		return nil, err
+	}
+
+	// Optional password; visitors must enter it before being redirected
+	if password := r.FormValue("password"); password != "" {
+		// bcrypt ignores anything past 72 bytes
+		if len(password) > 72 {
+			return nil, errors.New("Password must be at most 72 bytes long")
+		}
+		if err := link.SetPassword(password); err != nil {
+			return nil, errors.New("Invalid password")
+		}
+	}
+
+	if link.IsVolatile() && links.IsPermanentRedirect(link.RedirectType) {
+		return nil, errors.New("Click-limited, scheduled or password-protected links can't use a permanent redirect")
+	}
+	return link, nil
 }
 
 func validatePreviewRequest(r *http.Request) (string, error) {
@@ -185,21 +387,22 @@ func ParseAndGetURL(r *http.Request) (string, error) {
 	return url, nil
 }
 
-func retrieveLongURL(ctx context.Context, db *sql.DB, rdb *redis.Client, code string) (uint64, string) {
-	id := utils.Base62Decode(code)
-	var longURL string
-
+func retrieveLink(ctx context.Context, db *sql.DB, rdb *redis.Client, code string) *links.Link {
 	// Try Redis
-	key := "code_to_long:" + code
-	if longURL, err := rdb.Get(ctx, key).Result(); err == nil {
-		return id, longURL
+	if link, err := links.Cached(ctx, rdb, code); err == nil {
+		return link
 	}
 	// Redis miss -> Try SQLite
-	if db.QueryRowContext(ctx, "SELECT long_url FROM urls WHERE id = ?", id).Scan(&longURL) != nil {
-		return 0, ""
+	id, err := links.LookupCode(ctx, db, code)
+	if err != nil {
+		return nil
 	}
-	storeShortKeyInRedis(ctx, rdb, code, longURL)
-	return id, longURL
+	link, err := links.Get(ctx, db, id)
+	if err != nil {
+		return nil
+	}
+	storeLinkInRedis(ctx, rdb, code, link)
+	return link
 }
 
 func retrieveClickStats(w http.ResponseWriter, ctx context.Context, db *sql.DB, id uint64) (int, string) {
@@ -225,39 +428,106 @@ func retrieveClickStats(w http.ResponseWriter, ctx context.Context, db *sql.DB,
 	return clickCount, lastVisited
 }
 
-func storeShortAndLongKeysInRedis(ctx context.Context, rdb *redis.Client, code string, longURL string, id int64) {
-	// Store code -> longURL mapping
-	storeShortKeyInRedis(ctx, rdb, code, longURL)
+// findPlainLink returns the code of an existing link equivalent to the
+// plain link, or "" if there is none yet
+func findPlainLink(ctx context.Context, db *sql.DB, rdb *redis.Client, link *links.Link, dedupKey string) (string, error) {
+	if !bloom.MightExist(dedupKey) {
+		return "", nil
+	}
+
+	// Try Redis
+	if cachedID, err := rdb.Get(ctx, links.DedupCacheKey(link.CreatorID, dedupKey)).Result(); err == nil {
+		id, _ := strconv.ParseUint(cachedID, 10, 64)
+		return utils.Base62Encode(id), nil
+	}
+
+	// Redis miss -> Try SQLite
+	existing, err := links.FindPlain(ctx, db, dedupKey, link.CreatorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	code := utils.Base62Encode(existing.ID)
+	storeShortAndLongKeysInRedis(ctx, rdb, code, existing, dedupKey)
+	return code, nil
+}
+
+// announceLink stores a newly created link in Bloom and Redis,
+// and lets the worker fetch the destination's metadata
+func announceLink(ctx context.Context, rdb *redis.Client, code string, link *links.Link, dedupKey string) {
+	analytics.PublishLinkEvent(rdb, events.LinkEvent{
+		Type:      events.LinkCreated,
+		ID:        link.ID,
+		Code:      code,
+		CreatorID: link.CreatorID,
+	})
+
+	if link.IsPlain() {
+		bloom.Add(dedupKey)
+		storeShortAndLongKeysInRedis(ctx, rdb, code, link, dedupKey)
+	} else {
+		storeLinkInRedis(ctx, rdb, code, link)
+	}
+}
 
-	hashedURL := utils.HashURL(longURL)
-	longKey := "long_to_id:" + hashedURL
-	ttl := 24 * time.Hour
+func storeShortAndLongKeysInRedis(ctx context.Context, rdb *redis.Client, code string, link *links.Link, dedupKey string) {
+	// Store code -> link mapping
+	storeLinkInRedis(ctx, rdb, code, link)
 
 	// Store longURL -> id mapping
-	_ = rdb.Set(ctx, longKey, fmt.Sprint(id), ttl).Err()
+	links.CacheDedup(ctx, rdb, link, dedupKey)
 }
 
-func storeShortKeyInRedis(ctx context.Context, rdb *redis.Client, code string, longURL string) {
-	ttl := 24 * time.Hour
-	shortKey := "code_to_long:" + code
-	_ = rdb.Set(ctx, shortKey, longURL, ttl).Err()
+func storeLinkInRedis(ctx context.Context, rdb *redis.Client, code string, link *links.Link) {
+	links.Cache(ctx, rdb, code, link)
+}
+
+// setRedirectCacheControl lets browsers and proxies cache permanent
+// redirects, and stops them caching temporary ones so every visit
+// reaches the server (and gets counted)
+func setRedirectCacheControl(w http.ResponseWriter, status int) {
+	if links.IsPermanentRedirect(status) {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		return
+	}
+	w.Header().Set("Cache-Control", "private, no-store, max-age=0")
+}
+
+// shortURLResponse is a created (or reused) short link
+type shortURLResponse struct {
+	Code     string `json:"code"`
+	ShortURL string `json:"short_url"`
 }
 
 func writeShortURL(w http.ResponseWriter, r *http.Request, code string) {
+	// Write Response
+	data := shortURLResponse{code, shortURLFor(r, code)}
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusCreated, data)
+		return
+	}
+	ui.Render(w, http.StatusCreated, "short-url.html", data)
+}
+
+// wantsJSON reports whether the client asked for JSON
+// instead of the HTML fragments used by the UI
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// shortURLFor returns the public short URL of code, as seen by the client
+func shortURLFor(r *http.Request, code string) string {
 	protocol := r.Header.Get("X-Forwarded-Proto")
 	if protocol == "" {
 		protocol = "http"
 	}
-	shortURL := fmt.Sprintf("%s://%s/%s", protocol, r.Host, code)
-
-	// Write Response
-	htmlSnippet := fmt.Sprintf(`
-		<div class="p-4 bg-green-100 text-green-700 rounded">
-			<p class="mb-1 font-semibold">Short URL:</p> 
-			<a href="%s" target="_blank" class="underline font-medium">%s</a>
-		</div>
-	`, shortURL, shortURL)
-
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(htmlSnippet))
+	return fmt.Sprintf("%s://%s/%s", protocol, r.Host, code)
 }
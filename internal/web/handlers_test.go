@@ -0,0 +1,70 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/bots"
+	"url-shortener/internal/config"
+	"url-shortener/internal/db"
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestBotsDontSpendClickBudgets(t *testing.T) {
+	ctx := context.Background()
+	if _, err := bots.InitBots(""); err != nil {
+		t.Fatal(err)
+	}
+	conn := db.InitSQLite(filepath.Join(t.TempDir(), "urls.db"))
+	t.Cleanup(func() { conn.Close() })
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	cfg := &config.Config{InterstitialMode: links.InterstitialModeOff, DefaultRedirectStatus: http.StatusFound}
+
+	link := &links.Link{LongURL: "https://example.com/once", MaxClicks: 1}
+	if err := links.Create(ctx, conn, link, link.LongURL); err != nil {
+		t.Fatal(err)
+	}
+	code := utils.Base62Encode(link.ID)
+
+	visit := func(method, userAgent string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/"+code, nil)
+		r.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		RedirectURL(w, r, code, "", conn, rdb, cfg)
+		return w
+	}
+
+	// Unfurlers and probes are redirected without using the one click
+	for _, v := range []struct{ method, userAgent string }{
+		{http.MethodGet, "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+		{http.MethodGet, "WhatsApp/2.23.20.0 A"},
+		{http.MethodHead, browserUA},
+		{http.MethodGet, "curl/8.5.0"},
+	} {
+		w := visit(v.method, v.userAgent)
+		if w.Code != http.StatusFound || w.Header().Get("Location") != link.LongURL {
+			t.Errorf("%s %q: %d %s, want a redirect", v.method, v.userAgent, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	// The person still gets through, once
+	if w := visit(http.MethodGet, browserUA); w.Code != http.StatusFound {
+		t.Fatalf("person: %d, want a redirect", w.Code)
+	}
+	if w := visit(http.MethodGet, browserUA); w.Code != http.StatusGone {
+		t.Errorf("second person: %d, want %d", w.Code, http.StatusGone)
+	}
+	// Once spent, bots aren't redirected either
+	if w := visit(http.MethodGet, "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"); w.Code != http.StatusGone {
+		t.Errorf("bot after the budget is spent: %d, want %d", w.Code, http.StatusGone)
+	}
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/links"
+	"url-shortener/internal/metadata"
+	"url-shortener/internal/web/ui"
+)
+
+// linkInfo is where a short link leads, as shown on its info page
+type linkInfo struct {
+	Code        string             `json:"code"`
+	ShortURL    string             `json:"short_url"`
+	Domain      string             `json:"domain,omitempty"`
+	LongURL     string             `json:"long_url,omitempty"`
+	Rules       []links.Rule       `json:"rules,omitempty"`
+	Variants    []links.Variant    `json:"variants,omitempty"`
+	Meta        *metadata.Metadata `json:"metadata,omitempty"`
+	Protected   bool               `json:"protected"`
+	CreatedAt   string             `json:"created_at,omitempty"`
+	TotalClicks int                `json:"total_clicks"`
+}
+
+// LinkInfo renders a public page showing where the link with the given
+// code leads, so visitors can check it without following it. Clients
+// sending "Accept: application/json" get the same data as JSON.
+func LinkInfo(w http.ResponseWriter, r *http.Request, code string, db *sql.DB, rdb *redis.Client) {
+	ctx := r.Context()
+
+	link := retrieveLink(ctx, db, rdb, code)
+	if link == nil {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	}
+	totalClicks, lastVisited := retrieveClickStats(w, ctx, db, link.ID)
+	if lastVisited == "" {
+		// Error response already written
+		return
+	}
+
+	// Write Response
+	// The destination of a protected link is only revealed after unlocking it
+	data := linkInfo{
+		Code:        code,
+		ShortURL:    shortURLFor(r, code),
+		Protected:   link.IsProtected(),
+		TotalClicks: totalClicks,
+	}
+	if !link.CreatedAt.IsZero() {
+		data.CreatedAt = link.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !data.Protected {
+		data.LongURL = link.LongURL
+		data.Rules = link.Rules
+		data.Variants = link.Variants
+		if u, err := url.Parse(link.LongURL); err == nil {
+			data.Domain = u.Hostname()
+		}
+
+		// Missing until the worker has fetched the destination
+		data.Meta, _ = metadata.Get(ctx, db, link.ID)
+	}
+
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, data)
+		return
+	}
+	// Links created before creation dates were recorded have none
+	if data.CreatedAt == "" {
+		data.CreatedAt = "Unknown"
+	} else {
+		data.CreatedAt = link.CreatedAt.UTC().Format("Jan 2, 2006 15:04 MST")
+	}
+	ui.Render(w, http.StatusOK, "link-info.html", data)
+}
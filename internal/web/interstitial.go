@@ -0,0 +1,61 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/links"
+	"url-shortener/internal/metadata"
+	"url-shortener/internal/web/ui"
+)
+
+// renderInterstitial shows where the link leads instead of redirecting,
+// and lets the visitor decide whether to continue
+func renderInterstitial(w http.ResponseWriter, ctx context.Context, db *sql.DB, link *links.Link, destination string) {
+	// Whether the page is shown changes as the link ages
+	w.Header().Set("Cache-Control", "private, no-store, max-age=0")
+
+	data := struct {
+		Domain      string
+		Destination string
+		Suspicious  bool
+		Meta        *metadata.Metadata
+	}{Destination: destination, Suspicious: link.Suspicious}
+
+	if u, err := url.Parse(destination); err == nil {
+		data.Domain = u.Hostname()
+	}
+
+	// Only LongURL's metadata is fetched, so it would be misleading
+	// next to a targeted or variant destination
+	if destination == link.LongURL {
+		data.Meta, _ = metadata.Get(ctx, db, link.ID)
+	}
+	ui.Render(w, http.StatusOK, "interstitial.html", data)
+}
+
+// parseInterstitial reads the link's optional interstitial setting and
+// records who is creating the link. Skipping the interstitial is
+// reserved for trusted creators, and flagged URLs always get it.
+func parseInterstitial(r *http.Request, link *links.Link) error {
+	creator := auth.FromContext(r.Context())
+	if creator != nil {
+		link.CreatorID = creator.ID
+		link.CreatorTrusted = creator.Trusted
+	}
+
+	link.Interstitial = r.FormValue("interstitial")
+	if !links.ValidInterstitial(link.Interstitial) {
+		return errors.New("Invalid interstitial: must be one of always, never")
+	}
+	if link.Interstitial == links.InterstitialNever && !link.CreatorTrusted {
+		return errors.New("Only trusted creators can skip the interstitial")
+	}
+
+	link.Suspicious = links.LooksSuspicious(link.LongURL)
+	return nil
+}
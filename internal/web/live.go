@@ -0,0 +1,157 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/events"
+	"url-shortener/internal/links"
+	"url-shortener/internal/live"
+	"url-shortener/internal/utils"
+)
+
+// keepAliveInterval is how often an idle event stream sends a comment,
+// so proxies don't close it
+const keepAliveInterval = 25 * time.Second
+
+// liveClick is the data of a "click" server-sent event
+type liveClick struct {
+	Code    string `json:"code"`
+	Time    string `json:"time"`
+	Country string `json:"country,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+// liveStats is the data of the "stats" event sent when a link's
+// stream opens, so reconnecting clients catch up on missed clicks
+type liveStats struct {
+	TotalClicks int64      `json:"total_clicks"`
+	LastVisited *time.Time `json:"last_visited,omitempty"`
+}
+
+// LinkEvents streams the clicks of the link with the given code as
+// server-sent events. Like its stats, a link's clicks are public.
+func LinkEvents(w http.ResponseWriter, r *http.Request, code string, db *sql.DB) {
+	ctx := r.Context()
+
+	id, err := links.LookupCode(ctx, db, code)
+	if err != nil {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	}
+	totalClicks, lastVisited, err := links.ClickStats(ctx, db, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	streamClicks(w, r, db, liveStats{totalClicks, lastVisited}, func(event events.ClickEvent) bool {
+		return event.ID == id
+	})
+}
+
+// AccountEvents streams the clicks of every link created with the
+// caller's API key as server-sent events
+func AccountEvents(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	creator := auth.FromContext(r.Context())
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	streamClicks(w, r, db, nil, func(event events.ClickEvent) bool {
+		return event.CreatorID == creator.ID
+	})
+}
+
+/**** Helper Methods below ****/
+
+// streamClicks relays the click events accepted by match until the
+// client hangs up. If stats is set, it is sent first. Each client IP
+// may only hold a few streams at once.
+func streamClicks(w http.ResponseWriter, r *http.Request, db *sql.DB, stats any, match func(events.ClickEvent) bool) {
+	ctx := r.Context()
+
+	if !live.Enabled {
+		http.Error(w, "Click stream unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	stream, err := live.Subscribe(utils.GetIP(r))
+	if err != nil {
+		http.Error(w, "Too many open streams", http.StatusTooManyRequests)
+		return
+	}
+	defer stream.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if stats != nil {
+		writeEvent(w, "stats", stats)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	// Codes of the links clicked so far
+	known := make(map[uint64]string)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-stream.Clicks():
+			// Like click stats, live clicks leave bots out
+			if event.Bot != "" || !match(event) {
+				continue
+			}
+			writeEvent(w, "click", liveClick{
+				Code:    clickedCode(ctx, db, known, event.ID),
+				Time:    event.TS,
+				Country: event.Country,
+				Variant: event.Variant,
+				Rule:    event.Rule,
+			})
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// clickedCode returns the code of the link, remembering it in known
+func clickedCode(ctx context.Context, db *sql.DB, known map[uint64]string, id uint64) string {
+	code, ok := known[id]
+	if !ok {
+		code = links.CodeOf(ctx, db, id)
+		known[id] = code
+	}
+	return code
+}
+
+// writeEvent writes one server-sent event with JSON data
+func writeEvent(w http.ResponseWriter, name string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+}
@@ -0,0 +1,24 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"url-shortener/internal/db"
+)
+
+func TestLinkEventsUnknownLink(t *testing.T) {
+	conn := db.InitSQLite(filepath.Join(t.TempDir(), "urls.db"))
+	t.Cleanup(func() { conn.Close() })
+
+	// An issued code whose link doesn't exist, or no longer does
+	for _, code := range []string{"6LAzd", "no-such-code"} {
+		w := httptest.NewRecorder()
+		LinkEvents(w, httptest.NewRequest("GET", "/"+code+"/events", nil), code, conn)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: %d %q, want %d", code, w.Code, w.Body.String(), http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/internal/web/ui"
+)
+
+// apiSpec is the OpenAPI 3 document served at /api/openapi.json. It is
+// generated once from apiOperations, with the schemas of JSON bodies
+// derived from the Go types the handlers encode, so it can't drift from
+// the responses actually sent.
+var apiSpec = buildSpec(apiOperations)
+
+// OpenAPISpec serves the OpenAPI document describing every route
+func OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, apiSpec)
+}
+
+// APIDocs renders a human-readable version of the OpenAPI document
+func APIDocs(w http.ResponseWriter, r *http.Request) {
+	schemas := make(map[string]string)
+	for name, s := range apiSpec.Components.Schemas {
+		pretty, _ := json.MarshalIndent(s, "", "  ")
+		schemas[name] = string(pretty)
+	}
+
+	data := struct {
+		Operations []apiOperation
+		Schemas    map[string]string
+	}{apiOperations, schemas}
+	ui.Render(w, http.StatusOK, "api-docs.html", data)
+}
+
+// apiOperation documents one route of the router
+type apiOperation struct {
+	Method string
+
+	// Path is an OpenAPI path template, e.g. "/{code}/qr". A trailing
+	// "{path}" stands for the rest of the path (chi's "*").
+	Path string
+
+	Tag         string
+	Summary     string
+	Description string
+
+	// APIKey is apiKeyOptional, apiKeyRequired or empty if keys are ignored
+	APIKey string
+
+	// RateLimited routes may answer 429 Too Many Requests
+	RateLimited bool
+
+	Params []apiParam
+
+	// Form fields of an application/x-www-form-urlencoded body
+	Form []apiParam
+
+	// Other request bodies, by content type
+	Body map[string]any
+
+	Responses []apiResponse
+}
+
+// apiParam is a path or query parameter, or a form field
+type apiParam struct {
+	Name        string
+	In          string // "path" or "query", empty for form fields
+	Type        string // JSON Schema type, "string" if empty
+	Format      string
+	Enum        []string
+	Required    bool
+	Description string
+}
+
+// apiResponse is one possible response of an operation. Content maps
+// content types to a schema or a Go value whose type describes the body.
+type apiResponse struct {
+	Status      int
+	Description string
+	Content     map[string]any
+	Headers     map[string]string
+}
+
+// schema is a JSON Schema object
+type schema map[string]any
+
+const (
+	apiKeyOptional = "optional"
+	apiKeyRequired = "required"
+)
+
+// openAPIDocument is the subset of OpenAPI 3 used by apiSpec
+type openAPIDocument struct {
+	OpenAPI    string                       `json:"openapi"`
+	Info       map[string]string            `json:"info"`
+	Paths      map[string]map[string]schema `json:"paths"`
+	Components openAPIComponents            `json:"components"`
+	Tags       []map[string]string          `json:"tags,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]schema `json:"schemas"`
+	Responses       map[string]schema `json:"responses"`
+	SecuritySchemes map[string]schema `json:"securitySchemes"`
+}
+
+/**** Helper Methods below ****/
+
+// buildSpec generates the OpenAPI document of the given operations
+func buildSpec(ops []apiOperation) *openAPIDocument {
+	b := &specBuilder{schemas: map[string]schema{
+		"Error": {
+			"type":        "string",
+			"description": `Plain-text message, e.g. "Link not found!"`,
+		},
+		"ShortenForm": formSchema(shortenForm),
+		"ImportRow": {
+			"allOf": []any{
+				schemaRef("ShortenForm"),
+				formSchema(importFields),
+			},
+		},
+	}}
+
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: map[string]string{
+			"title":       "miniurl",
+			"version":     "1.0.0",
+			"description": "URL shortener. Errors are sent as plain text; see the Error schema.",
+		},
+		Paths: make(map[string]map[string]schema),
+		Components: openAPIComponents{
+			Schemas: b.schemas,
+			Responses: map[string]schema{
+				"RateLimited": {
+					"description": "Rate limit exceeded",
+					"headers": map[string]any{
+						"Retry-After":           header("integer", "Seconds to wait before retrying"),
+						"X-RateLimit-Limit":     header("integer", "Requests allowed per window"),
+						"X-RateLimit-Remaining": header("integer", "Requests left in the window, always 0"),
+						"X-RateLimit-Reset":     header("integer", "Unix time at which the window resets"),
+					},
+					"content": map[string]any{"text/plain": map[string]any{"schema": schemaRef("Error")}},
+				},
+			},
+			SecuritySchemes: map[string]schema{
+				"ApiKey":     {"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"BearerAuth": {"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+
+	tags := make(map[string]bool)
+	for _, op := range ops {
+		if !tags[op.Tag] {
+			tags[op.Tag] = true
+			doc.Tags = append(doc.Tags, map[string]string{"name": op.Tag})
+		}
+		if doc.Paths[op.Path] == nil {
+			doc.Paths[op.Path] = make(map[string]schema)
+		}
+		doc.Paths[op.Path][strings.ToLower(op.Method)] = b.operation(op)
+	}
+	return doc
+}
+
+// specBuilder collects the schemas of the Go types used by operations
+type specBuilder struct {
+	schemas map[string]schema
+}
+
+func (b *specBuilder) operation(op apiOperation) schema {
+	out := schema{
+		"tags":        []string{op.Tag},
+		"summary":     op.Summary,
+		"operationId": operationID(op),
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+
+	var params []schema
+	for _, p := range op.Params {
+		params = append(params, schema{
+			"name":        p.Name,
+			"in":          p.In,
+			"required":    p.Required || p.In == "path",
+			"description": p.Description,
+			"schema":      paramSchema(p),
+		})
+	}
+	if params != nil {
+		out["parameters"] = params
+	}
+
+	content := make(map[string]any)
+	if op.Form != nil {
+		content["application/x-www-form-urlencoded"] = map[string]any{"schema": formSchema(op.Form)}
+	}
+	for contentType, body := range op.Body {
+		content[contentType] = map[string]any{"schema": b.schemaFor(body)}
+	}
+	if len(content) > 0 {
+		out["requestBody"] = schema{"required": true, "content": content}
+	}
+
+	responses := make(map[string]any)
+	for _, res := range op.Responses {
+		r := schema{"description": res.Description}
+		if len(res.Content) > 0 {
+			content := make(map[string]any)
+			for contentType, body := range res.Content {
+				content[contentType] = map[string]any{"schema": b.schemaFor(body)}
+			}
+			r["content"] = content
+		}
+		if len(res.Headers) > 0 {
+			headers := make(map[string]any)
+			for name, description := range res.Headers {
+				headers[name] = header("string", description)
+			}
+			r["headers"] = headers
+		}
+		responses[strconv.Itoa(res.Status)] = r
+	}
+	switch op.APIKey {
+	case apiKeyOptional:
+		out["security"] = []schema{{}, {"ApiKey": []string{}}, {"BearerAuth": []string{}}}
+		responses["401"] = errorResponse("Invalid API key")
+	case apiKeyRequired:
+		out["security"] = []schema{{"ApiKey": []string{}}, {"BearerAuth": []string{}}}
+		responses["401"] = errorResponse("Missing or invalid API key")
+	}
+	if op.RateLimited {
+		responses["429"] = schemaRef("RateLimited", "responses")
+	}
+	out["responses"] = responses
+	return out
+}
+
+// schemaFor returns v itself if it is a schema,
+// or the schema of v's Go type otherwise
+func (b *specBuilder) schemaFor(v any) schema {
+	if s, ok := v.(schema); ok {
+		return s
+	}
+	return b.schemaOf(reflect.TypeOf(v))
+}
+
+// schemaOf describes how encoding/json encodes values of type t.
+// Named structs are added to the components and referenced.
+func (b *specBuilder) schemaOf(t reflect.Type) schema {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return schema{"type": "string", "format": "date-time"}
+	case reflect.TypeFor[json.RawMessage]():
+		// Embedded as is, any JSON value
+		return schema{}
+	case reflect.TypeFor[[]byte]():
+		return schema{"type": "string", "format": "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := b.schemaOf(t.Elem())
+		if t.Elem().Kind() != reflect.Struct {
+			s["nullable"] = true
+		}
+		return s
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := b.schemas[name]; !ok {
+			// Reserve the name first in case the type refers to itself
+			b.schemas[name] = schema{}
+			b.schemas[name] = b.structSchema(t)
+		}
+		return schemaRef(name)
+	}
+	return schema{}
+}
+
+func (b *specBuilder) structSchema(t reflect.Type) schema {
+	properties := make(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+	s := schema{"type": "object", "properties": properties}
+	if required != nil {
+		s["required"] = required
+	}
+	return s
+}
+
+// formSchema describes form fields, which are all sent as strings
+func formSchema(fields []apiParam) schema {
+	properties := make(map[string]any)
+	var required []string
+	for _, field := range fields {
+		s := paramSchema(field)
+		if field.Description != "" {
+			s["description"] = field.Description
+		}
+		properties[field.Name] = s
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+	s := schema{"type": "object", "properties": properties}
+	if required != nil {
+		s["required"] = required
+	}
+	return s
+}
+
+func paramSchema(p apiParam) schema {
+	s := schema{"type": "string"}
+	if p.Type != "" {
+		s["type"] = p.Type
+	}
+	if p.Format != "" {
+		s["format"] = p.Format
+	}
+	if p.Enum != nil {
+		s["enum"] = p.Enum
+	}
+	return s
+}
+
+// schemaRef points to a component, a schema unless kind says otherwise
+func schemaRef(name string, kind ...string) schema {
+	section := "schemas"
+	if len(kind) > 0 {
+		section = kind[0]
+	}
+	return schema{"$ref": "#/components/" + section + "/" + name}
+}
+
+func errorResponse(description string) schema {
+	return schema{
+		"description": description,
+		"content":     map[string]any{"text/plain": map[string]any{"schema": schemaRef("Error")}},
+	}
+}
+
+func header(typ, description string) schema {
+	return schema{"description": description, "schema": schema{"type": typ}}
+}
+
+// operationID derives a stable id from the method and path,
+// e.g. "get_code_qr" for GET /{code}/qr
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		id += "_" + part
+	}
+	if strings.HasSuffix(op.Path, "+") {
+		id += "_plus"
+	}
+	if op.Path == "/" {
+		id += "_index"
+	}
+	return id
+}
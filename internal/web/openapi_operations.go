@@ -0,0 +1,428 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"url-shortener/internal/links"
+	"url-shortener/internal/webhooks"
+)
+
+// apiOperations documents every route registered by New, in the same
+// order. A test fails if a route is missing here.
+var apiOperations = []apiOperation{
+	{
+		Method: http.MethodGet, Path: "/static/{path}", Tag: "UI",
+		Summary: "Static assets of the web UI",
+		Params:  []apiParam{{Name: "path", In: "path", Description: "File under static/"}},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The file"},
+			{Status: 404, Description: "No such file"},
+		},
+	},
+	{
+		Method: http.MethodHead, Path: "/static/{path}", Tag: "UI",
+		Summary: "Headers of a static asset",
+		Params:  []apiParam{{Name: "path", In: "path", Description: "File under static/"}},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The file exists"},
+			{Status: 404, Description: "No such file"},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/ui/form", Tag: "UI",
+		Summary: "URL form fragment used by the web UI",
+		Params: []apiParam{
+			{Name: "label", In: "query"},
+			{Name: "placeholder", In: "query"},
+			{Name: "endpoint", In: "query", Description: "Where the form is posted"},
+		},
+		Responses: []apiResponse{htmlResponse(200, "The form")},
+	},
+	{
+		Method: http.MethodGet, Path: "/", Tag: "UI",
+		Summary:   "Web UI",
+		Responses: []apiResponse{htmlResponse(200, "The index page")},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/openapi.json", Tag: "Docs",
+		Summary: "This OpenAPI document",
+		Responses: []apiResponse{
+			{Status: 200, Description: "The document", Content: map[string]any{"application/json": schema{"type": "object"}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/docs", Tag: "Docs",
+		Summary:   "Human-readable API documentation",
+		Responses: []apiResponse{htmlResponse(200, "The docs page")},
+	},
+	{
+		Method: http.MethodPost, Path: "/shorten-url", Tag: "Links",
+		Summary: "Shorten a URL",
+		Description: "Plain links (without options) are deduplicated: shortening the same URL again " +
+			"returns the existing link. Links created with an API key are only deduplicated against that key's links.",
+		APIKey: apiKeyOptional, RateLimited: true,
+		Body: map[string]any{"application/x-www-form-urlencoded": schemaRef("ShortenForm")},
+		Responses: []apiResponse{
+			{Status: 201, Description: "The short link; JSON if requested with Accept: application/json", Content: map[string]any{
+				"text/html":        schema{"type": "string"},
+				"application/json": shortURLResponse{},
+			}},
+			plainError(400, "Invalid URL or options"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/shorten-bulk", Tag: "Links",
+		Summary: "Shorten many URLs at once",
+		Description: "Rows take the same fields as the shorten form and are validated and deduplicated the same way. " +
+			"New links are inserted in a single transaction; rejected rows don't stop the others. " +
+			"A row repeating the plain URL of an earlier row shares its link and names it in duplicate_of.",
+		APIKey: apiKeyRequired, RateLimited: true,
+		Body: map[string]any{
+			"application/json":     schema{"type": "array", "items": schemaRef("ShortenForm")},
+			"application/x-ndjson": schema{"type": "string", "description": "One ShortenForm object per line"},
+			"text/csv":             schema{"type": "string", "description": "A header row naming ShortenForm fields, then one row per link"},
+			"multipart/form-data":  csvUpload,
+		},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The outcome of every row", Content: map[string]any{"application/json": bulkSummary{}}},
+			plainError(400, "Unreadable body or no rows"),
+			plainError(413, "Too many rows for the API key's limit"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/export", Tag: "Links",
+		Summary:     "Export the caller's links",
+		Description: "Streams every link created with the API key, oldest first, with its stats and metadata.",
+		APIKey:      apiKeyRequired, RateLimited: true,
+		Params: []apiParam{
+			{Name: "format", In: "query", Enum: []string{"csv", "ndjson"}, Description: "csv if empty"},
+		},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The links", Content: map[string]any{
+				"text/csv":             schema{"type": "string", "description": "A header row, then one ExportRow per line"},
+				"application/x-ndjson": links.ExportRow{},
+			}},
+			plainError(400, "Invalid format"),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/import", Tag: "Links",
+		Summary: "Import links keeping their codes",
+		Description: "Takes an export (from this or another shortener) and recreates its links with their codes, " +
+			"creation dates and click stats in a single transaction. Codes this service could issue itself " +
+			"are only accepted for link ids it has already handed out and that are free again.",
+		APIKey: apiKeyRequired, RateLimited: true,
+		Body: map[string]any{
+			"application/json":     schema{"type": "array", "items": schemaRef("ImportRow")},
+			"application/x-ndjson": schema{"type": "string", "description": "One ImportRow object per line"},
+			"text/csv":             schema{"type": "string", "description": "A header row naming ImportRow fields, then one row per link"},
+			"multipart/form-data":  csvUpload,
+		},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The outcome of every row", Content: map[string]any{"application/json": bulkSummary{}}},
+			plainError(400, "Unreadable body or no rows"),
+			plainError(413, "Too many rows for the API key's limit"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/webhooks", Tag: "Webhooks",
+		Summary: "Subscribe to events of the caller's links",
+		Description: "Deliveries are signed JSON POSTs, retried with exponential backoff. " +
+			"The secret used to sign them is only returned here.",
+		APIKey: apiKeyRequired, RateLimited: true,
+		Form: []apiParam{
+			{Name: "url", Required: true, Description: "The receiver"},
+			{Name: "events", Description: "Comma-separated event types, all of them if empty: " + strings.Join(webhooks.Events, ", ")},
+		},
+		Responses: []apiResponse{
+			{Status: 201, Description: "The webhook, with its secret", Content: map[string]any{"application/json": webhooks.Webhook{}}},
+			plainError(400, "Invalid URL or event, or too many webhooks"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/webhooks", Tag: "Webhooks",
+		Summary: "List the caller's webhooks",
+		APIKey:  apiKeyRequired, RateLimited: true,
+		Responses: []apiResponse{
+			{Status: 200, Description: "The webhooks, without secrets", Content: map[string]any{"application/json": []webhooks.Webhook{}}},
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/webhooks/{id}", Tag: "Webhooks",
+		Summary: "Unsubscribe a webhook and drop its delivery log",
+		APIKey:  apiKeyRequired, RateLimited: true,
+		Params: []apiParam{webhookIDParam},
+		Responses: []apiResponse{
+			{Status: 204, Description: "Deleted"},
+			plainError(404, "Webhook not found!"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/webhooks/{id}/deliveries", Tag: "Webhooks",
+		Summary:     "Delivery log of a webhook",
+		Description: "The latest 100 deliveries, newest first. Dead deliveries ran out of retries and won't be sent again.",
+		APIKey:      apiKeyRequired, RateLimited: true,
+		Params: []apiParam{
+			webhookIDParam,
+			{Name: "status", In: "query", Enum: []string{"pending", "delivered", "dead"}, Description: "Only list deliveries with this status"},
+		},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The deliveries", Content: map[string]any{"application/json": []webhooks.Delivery{}}},
+			plainError(400, "Invalid status"),
+			plainError(404, "Webhook not found!"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/events", Tag: "Stats",
+		Summary: "Live clicks of the caller's links",
+		Description: "A server-sent event stream with a \"click\" event for every visit to a link created with the API key. " +
+			"A client IP may hold a few streams at once; further ones get a 429.",
+		APIKey: apiKeyRequired, RateLimited: true,
+		Responses: []apiResponse{
+			eventStreamResponse("One \"click\" event per visit"),
+			plainError(503, "Click stream unavailable"),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/track-clicks", Tag: "Stats",
+		Summary:     "Click stats of a short link",
+		RateLimited: true,
+		Form:        []apiParam{{Name: "url", Required: true, Description: "The short URL"}},
+		Responses: []apiResponse{
+			htmlResponse(200, "Total and bot clicks, unique visitors, last visit and per-variant clicks"),
+			plainError(400, "Not a short URL of this service"),
+			plainError(404, "Link not found!"),
+			plainError(500, "Database error"),
+			plainError(503, "Service temporarily unavailable"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/campaign-stats", Tag: "Stats",
+		Summary: "Link and click totals of the caller's UTM campaigns",
+		APIKey:  apiKeyRequired, RateLimited: true,
+		Params: []apiParam{
+			{Name: "group_by", In: "query", Enum: []string{"campaign", "source", "medium"}, Description: "campaign if empty"},
+			{Name: "campaign", In: "query", Description: "Only count links of this campaign"},
+			{Name: "source", In: "query", Description: "Only count links of this source"},
+			{Name: "medium", In: "query", Description: "Only count links of this medium"},
+		},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The totals; JSON if requested with Accept: application/json", Content: map[string]any{
+				"text/html":        schema{"type": "string"},
+				"application/json": []links.CampaignStat{},
+			}},
+			plainError(400, "Invalid grouping"),
+			plainError(500, "Database error"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/{code}", Tag: "Redirects",
+		Summary:     "Follow a short link",
+		RateLimited: true,
+		Params:      []apiParam{codeParam},
+		Responses:   redirectResponses,
+	},
+	{
+		Method: http.MethodHead, Path: "/{code}", Tag: "Redirects",
+		Summary:     "Check a short link",
+		Description: "Redirects like GET, but the click is counted as a bot click.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam},
+		Responses:   redirectResponses,
+	},
+	{
+		Method: http.MethodGet, Path: "/{code}+", Tag: "Links",
+		Summary:     "Where a short link leads",
+		Description: "Same as /{code}/info.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam},
+		Responses:   linkInfoResponses,
+	},
+	{
+		Method: http.MethodGet, Path: "/{code}/info", Tag: "Links",
+		Summary:     "Where a short link leads",
+		Description: "Shows the destination without following it. The destination of protected links stays hidden.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam},
+		Responses:   linkInfoResponses,
+	},
+	{
+		Method: http.MethodGet, Path: "/{code}/qr", Tag: "Links",
+		Summary:     "QR code of a short link",
+		RateLimited: true,
+		Params: []apiParam{
+			codeParam,
+			{Name: "format", In: "query", Enum: []string{"png", "svg"}},
+			{Name: "size", In: "query", Type: "integer", Description: "In pixels, 64-2048"},
+			{Name: "margin", In: "query", Type: "integer", Description: "In modules"},
+			{Name: "level", In: "query", Enum: []string{"L", "M", "Q", "H"}, Description: "Error correction"},
+			{Name: "fg", In: "query", Description: "Foreground colour as RRGGBB"},
+			{Name: "bg", In: "query", Description: "Background colour as RRGGBB"},
+			{Name: "download", In: "query", Description: "Set to save the image as a file"},
+		},
+		Responses: []apiResponse{
+			{Status: 200, Description: "The QR code", Content: map[string]any{
+				"image/png":     schema{"type": "string", "format": "binary"},
+				"image/svg+xml": schema{"type": "string"},
+			}},
+			{Status: 304, Description: "Not modified since the ETag sent"},
+			plainError(400, "Invalid options"),
+			plainError(404, "Link not found!"),
+			plainError(500, "Failed to render QR code"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/{code}/events", Tag: "Stats",
+		Summary: "Live clicks of a short link",
+		Description: "A server-sent event stream. It opens with a \"stats\" event holding the totals so far, then sends a \"click\" event for every visit. " +
+			"A client IP may hold a few streams at once; further ones get a 429. " +
+			"This path is never forwarded to the destination of a path-passthrough link.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam},
+		Responses: []apiResponse{
+			eventStreamResponse("A \"stats\" event, then one \"click\" event per visit"),
+			plainError(404, "Link not found!"),
+			plainError(500, "Database error"),
+			plainError(503, "Click stream unavailable"),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/{code}/{path}", Tag: "Redirects",
+		Summary: "Follow a short link with path passthrough",
+		Description: "For links created with pass_path, the extra path is appended to the destination's path. " +
+			"The paths info, qr and events are the shortener's own and never forwarded.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam, {Name: "path", In: "path", Description: "Extra path"}},
+		Responses:   redirectResponses,
+	},
+	{
+		Method: http.MethodHead, Path: "/{code}/{path}", Tag: "Redirects",
+		Summary:     "Check a short link with path passthrough",
+		Description: "Redirects like GET, but the click is counted as a bot click.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam, {Name: "path", In: "path", Description: "Extra path"}},
+		Responses:   redirectResponses,
+	},
+	{
+		Method: http.MethodPost, Path: "/{code}", Tag: "Redirects",
+		Summary:     "Unlock a password-protected link",
+		Description: "Limited to 5 attempts per IP per link every 15 minutes.",
+		RateLimited: true,
+		Params:      []apiParam{codeParam},
+		Form:        []apiParam{{Name: "password", Required: true}},
+		Responses: []apiResponse{
+			{Status: 303, Description: "Unlocked: back to the link, with a signed cookie", Headers: map[string]string{
+				"Location":   "The short link",
+				"Set-Cookie": "Grants access for an hour",
+			}},
+			htmlResponse(401, "Wrong password, with the prompt"),
+			plainError(400, "Bad Request"),
+			plainError(404, "Link not found!"),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/preview-url", Tag: "Links",
+		Summary:     "Preview where a short URL leads",
+		RateLimited: true,
+		Form:        []apiParam{{Name: "url", Required: true, Description: "The short URL"}},
+		Responses: []apiResponse{
+			htmlResponse(200, "The destination and its metadata"),
+			plainError(400, "Not a short URL of this service"),
+			plainError(404, "Link not found!"),
+		},
+	},
+}
+
+// shortenForm lists the fields of the shorten form
+var shortenForm = []apiParam{
+	{Name: "url", Required: true, Description: "The destination"},
+	{Name: "redirect_type", Enum: []string{"301", "302", "307", "308"}, Description: "The server's default if empty"},
+	{Name: "max_clicks", Type: "integer", Description: "Expires the link after this many clicks"},
+	{Name: "not_before", Description: "RFC 3339 time, or a datetime-local value in the tz_offset zone"},
+	{Name: "not_after", Description: "RFC 3339 time, or a datetime-local value in the tz_offset zone"},
+	{Name: "tz_offset", Type: "integer", Description: "Offset of datetime-local values from UTC in minutes, as given by getTimezoneOffset()"},
+	{Name: "fallback_url", Description: "Where visitors go outside of not_before and not_after"},
+	{Name: "target_ios", Description: "Destination for iOS visitors"},
+	{Name: "target_android", Description: "Destination for Android visitors"},
+	{Name: "target_windows", Description: "Destination for Windows visitors"},
+	{Name: "target_macos", Description: "Destination for macOS visitors"},
+	{Name: "target_linux", Description: "Destination for Linux visitors"},
+	{Name: "target_mobile", Description: "Destination for other mobile visitors"},
+	{Name: "target_desktop", Description: "Destination for other desktop visitors"},
+	{Name: "geo_rules", Description: `One "<country code> <url>" pair per line`},
+	{Name: "variants", Description: `One "<weight> <url>" pair per line, for an A/B split`},
+	{Name: "pass_query", Description: "true or 1 to merge the visitor's query string into the destination"},
+	{Name: "pass_path", Description: "true or 1 to append the visitor's extra path to the destination"},
+	{Name: "password", Description: "Visitors must enter it before being redirected (at most 72 bytes)"},
+	{Name: "interstitial", Enum: []string{"always", "never"}, Description: "never is only allowed for trusted API keys"},
+	{Name: "utm_source"},
+	{Name: "utm_medium"},
+	{Name: "utm_campaign"},
+	{Name: "utm_term"},
+	{Name: "utm_content"},
+}
+
+// importFields lists the fields imports take on top of the shorten form
+var importFields = []apiParam{
+	{Name: "code", Required: true, Description: "Letters, digits, - and _, at most 64"},
+	{Name: "created_at", Format: "date-time"},
+	{Name: "click_count", Type: "integer"},
+	{Name: "last_visited_at", Format: "date-time"},
+}
+
+var codeParam = apiParam{Name: "code", In: "path", Description: "The short link's code"}
+
+var webhookIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "The webhook's id"}
+
+var csvUpload = schema{
+	"type":       "object",
+	"properties": map[string]any{"file": schema{"type": "string", "format": "binary", "description": "A CSV file"}},
+	"required":   []string{"file"},
+}
+
+var redirectResponses = []apiResponse{
+	{Status: 301, Description: "Permanent redirect to the destination", Headers: map[string]string{"Location": "The destination"}},
+	{Status: 302, Description: "Temporary redirect to the destination (or fallback URL)", Headers: map[string]string{"Location": "The destination"}},
+	{Status: 307, Description: "Temporary redirect to the destination", Headers: map[string]string{"Location": "The destination"}},
+	{Status: 308, Description: "Permanent redirect to the destination", Headers: map[string]string{"Location": "The destination"}},
+	htmlResponse(200, "Safety interstitial, password prompt or coming-soon page"),
+	plainError(404, "Link not found!"),
+	plainError(410, "The link has been disabled, has expired or has no clicks left"),
+	plainError(503, "Service temporarily unavailable"),
+}
+
+var linkInfoResponses = []apiResponse{
+	{Status: 200, Description: "The link; JSON if requested with Accept: application/json", Content: map[string]any{
+		"text/html":        schema{"type": "string"},
+		"application/json": linkInfo{},
+	}},
+	plainError(404, "Link not found!"),
+	plainError(500, "Database error"),
+}
+
+func htmlResponse(status int, description string) apiResponse {
+	return apiResponse{Status: status, Description: description, Content: map[string]any{"text/html": schema{"type": "string"}}}
+}
+
+// eventStreamResponse describes a stream of server-sent events with JSON data
+func eventStreamResponse(description string) apiResponse {
+	return apiResponse{Status: 200, Description: description, Content: map[string]any{
+		"text/event-stream": schema{
+			"type": "string",
+			"description": "\"stats\" data: {total_clicks, last_visited}. " +
+				"\"click\" data: {code, time, country, variant, rule}.",
+		},
+	}}
+}
+
+func plainError(status int, description string) apiResponse {
+	return apiResponse{Status: status, Description: description, Content: map[string]any{"text/plain": schemaRef("Error")}}
+}
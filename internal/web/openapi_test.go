@@ -0,0 +1,49 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-shortener/internal/config"
+)
+
+// TestSpecCoversRoutes fails when the router exposes a route that the
+// OpenAPI document doesn't describe, or the document describes a route
+// the router doesn't have
+func TestSpecCoversRoutes(t *testing.T) {
+	router := New(nil, nil, &config.Config{}).(chi.Routes)
+
+	routed := make(map[string]bool)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path := specPath(route)
+		routed[method+" "+path] = true
+
+		if _, ok := apiSpec.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("%s %s is not described in the OpenAPI document", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, ops := range apiSpec.Paths {
+		for method := range ops {
+			if !routed[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is described but not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// specPath turns a chi route into an OpenAPI path template,
+// e.g. "/{code}/*" into "/{code}/{path}"
+func specPath(route string) string {
+	if strings.HasSuffix(route, "/*") {
+		return strings.TrimSuffix(route, "*") + "{path}"
+	}
+	return route
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"url-shortener/internal/links"
+)
+
+var errInvalidPath = errors.New("invalid path")
+
+// applyPassthrough forwards what the visitor added to the short URL
+// onto the destination, for links that opted in:
+//
+//   - PassPath: /{code}/extra/path appends "extra/path" to the
+//     destination's path. extraPath is escaped as the visitor sent it.
+//   - PassQuery: query params are merged into the destination's.
+//     On collision the destination's own value wins, so visitors
+//     can add params but never override the ones set by the owner.
+func applyPassthrough(destination string, link *links.Link, extraPath string, rawQuery string) (string, error) {
+	if !link.PassPath && !link.PassQuery {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	if link.PassPath && extraPath != "" {
+		// Don't let visitors climb out of the destination's path,
+		// whichever way they escape the dots
+		for _, segment := range strings.Split(extraPath, "/") {
+			segment, err := url.PathUnescape(segment)
+			if err != nil {
+				return "", errInvalidPath
+			}
+			for _, part := range strings.Split(segment, "/") {
+				if part == ".." || part == "." {
+					return "", errInvalidPath
+				}
+			}
+		}
+		// Join the escaped paths, so that e.g. "a%2Fb" stays one segment
+		rawPath := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.TrimPrefix(extraPath, "/")
+		if u.Path, err = url.PathUnescape(rawPath); err != nil {
+			return "", errInvalidPath
+		}
+		u.RawPath = rawPath
+	}
+
+	if link.PassQuery && rawQuery != "" {
+		if _, err := url.ParseQuery(rawQuery); err != nil {
+			return "", err
+		}
+		// Append the visitor's new params as sent, leaving the
+		// destination's own query exactly as the owner wrote it
+		query := u.Query()
+		var added []string
+		for _, param := range strings.Split(rawQuery, "&") {
+			if param == "" {
+				continue
+			}
+			rawKey, _, _ := strings.Cut(param, "=")
+			key, _ := url.QueryUnescape(rawKey)
+			if _, exists := query[key]; exists {
+				continue
+			}
+			added = append(added, param)
+		}
+		if len(added) > 0 && u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += strings.Join(added, "&")
+	}
+
+	return u.String(), nil
+}
+
+// extraPath returns what followed "/{code}/" in the request,
+// escaped as the visitor sent it
+func extraPath(r *http.Request, code string) string {
+	path, ok := strings.CutPrefix(r.URL.EscapedPath(), "/"+code+"/")
+	if !ok {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/links"
+)
+
+func TestApplyPassthrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		request     string
+		want        string
+		wantErr     bool
+	}{
+		{"path", "https://example.com/docs", "/6LAzd/guide/intro", "https://example.com/docs/guide/intro", false},
+		{"trailing slash", "https://example.com/docs/", "/6LAzd/intro", "https://example.com/docs/intro", false},
+		{"escaped slash", "https://example.com/files", "/6LAzd/a%2Fb", "https://example.com/files/a%2Fb", false},
+		{"escaped space", "https://example.com/files", "/6LAzd/my%20file.pdf", "https://example.com/files/my%20file.pdf", false},
+		{"escaped percent", "https://example.com/files", "/6LAzd/100%25", "https://example.com/files/100%25", false},
+		{"escaped destination", "https://example.com/a%2Fb", "/6LAzd/c", "https://example.com/a%2Fb/c", false},
+		{"dot dot", "https://example.com/docs", "/6LAzd/../admin", "", true},
+		{"escaped dot dot", "https://example.com/docs", "/6LAzd/%2e%2e/admin", "", true},
+		{"dot dot behind an escaped slash", "https://example.com/docs", "/6LAzd/a%2F..%2Fadmin", "", true},
+
+		{"query", "https://example.com/", "/6LAzd?ref=mail", "https://example.com/?ref=mail", false},
+		{"owner's query kept as is", "https://example.com/?b=2&a=x%20y", "/6LAzd?c=3", "https://example.com/?b=2&a=x%20y&c=3", false},
+		{"owner wins", "https://example.com/?ref=site&b=2", "/6LAzd?ref=mail&c=3", "https://example.com/?ref=site&b=2&c=3", false},
+		{"escaped owner key", "https://example.com/?a%5B%5D=1", "/6LAzd?a[]=2", "https://example.com/?a%5B%5D=1", false},
+		{"visitor's order and escaping", "https://example.com/?z=1", "/6LAzd?y=a+b&x=%2F&y=c", "https://example.com/?z=1&y=a+b&x=%2F&y=c", false},
+		{"bad query", "https://example.com/", "/6LAzd?a=%zz", "", true},
+
+		{"both", "https://example.com/docs?lang=en", "/6LAzd/a%2Fb?page=2", "https://example.com/docs/a%2Fb?lang=en&page=2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.request, nil)
+			link := &links.Link{PassPath: true, PassQuery: true}
+
+			got, err := applyPassthrough(tt.destination, link, extraPath(r, "6LAzd"), r.URL.RawQuery)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
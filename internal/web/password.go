@@ -0,0 +1,88 @@
+package web
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/web/ui"
+)
+
+// unlockCookieTTL is how long a visitor can follow a protected
+// link again without re-entering its password
+const unlockCookieTTL = time.Hour
+
+const unlockCookieName = "miniurl_unlock"
+
+// UnlockURL verifies the password submitted for a protected link.
+// On success it issues a short-lived signed cookie and sends the
+// visitor back to the short link, which then redirects as usual.
+func UnlockURL(w http.ResponseWriter, r *http.Request, code string, db *sql.DB, rdb *redis.Client, cfg *config.Config) {
+	ctx := r.Context()
+
+	link := retrieveLink(ctx, db, rdb, code)
+	if link == nil {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	}
+	if !link.IsProtected() {
+		http.Redirect(w, r, "/"+code, http.StatusSeeOther)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if !link.CheckPassword(r.PostFormValue("password")) {
+		renderPasswordPrompt(w, code, http.StatusUnauthorized, "Incorrect password")
+		return
+	}
+
+	setUnlockCookie(w, r, code, link, cfg)
+
+	// Post/Redirect/Get -> the click is counted by RedirectURL
+	http.Redirect(w, r, "/"+code, http.StatusSeeOther)
+}
+
+/**** Helper Methods below ****/
+
+func renderPasswordPrompt(w http.ResponseWriter, code string, status int, errMsg string) {
+	data := struct {
+		Action string
+		Error  string
+	}{"/" + code, errMsg}
+
+	// Never cache the prompt in place of the redirect
+	w.Header().Set("Cache-Control", "private, no-store, max-age=0")
+	ui.Render(w, status, "password.html", data)
+}
+
+// isUnlocked reports whether the visitor holds a valid unlock cookie
+// for this link. The password hash is part of the signed payload,
+// so changing the password invalidates all issued cookies.
+func isUnlocked(r *http.Request, code string, link *links.Link, cfg *config.Config) bool {
+	cookie, err := r.Cookie(unlockCookieName)
+	if err != nil {
+		return false
+	}
+	return utils.VerifyToken(cfg.CookieSecret, cookie.Value, code+"|"+link.PasswordHash)
+}
+
+func setUnlockCookie(w http.ResponseWriter, r *http.Request, code string, link *links.Link, cfg *config.Config) {
+	expires := time.Now().Add(unlockCookieTTL)
+	http.SetCookie(w, &http.Cookie{
+		Name:     unlockCookieName,
+		Value:    utils.SignToken(cfg.CookieSecret, code+"|"+link.PasswordHash, expires),
+		Path:     "/" + code,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/qr"
+	"url-shortener/internal/utils"
+)
+
+// QRCode renders the QR code of a short link as PNG or SVG.
+// Rendered images are cached in Redis, keyed by content and options.
+func QRCode(w http.ResponseWriter, r *http.Request, code string, db *sql.DB, rdb *redis.Client) {
+	ctx := r.Context()
+
+	opts, err := parseQROptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if retrieveLink(ctx, db, rdb, code) == nil {
+		http.Error(w, "Link not found!", http.StatusNotFound)
+		return
+	}
+
+	shortURL := shortURLFor(r, code)
+	cacheKey := "qr:" + utils.HashURL(shortURL+"|"+opts.Key())
+	etag := `"` + cacheKey[len("qr:"):len("qr:")+32] + `"`
+
+	// Same content + options -> same image
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Try Redis
+	img, err := rdb.Get(ctx, cacheKey).Bytes()
+	if err != nil {
+		// Redis miss -> Render
+		img, err = qr.Render(shortURL, opts)
+		if err != nil {
+			http.Error(w, "Failed to render QR code", http.StatusInternalServerError)
+			return
+		}
+		_ = rdb.Set(ctx, cacheKey, img, 24*time.Hour).Err()
+	}
+
+	w.Header().Set("Content-Type", opts.ContentType())
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", etag)
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+code+`.`+opts.Format+`"`)
+	}
+	w.Write(img)
+}
+
+/**** Helper Methods below ****/
+
+// parseQROptions reads format, size, margin, level (L/M/Q/H),
+// fg and bg (RRGGBB) from the query string
+func parseQROptions(r *http.Request) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	query := r.URL.Query()
+
+	if v := query.Get("format"); v != "" {
+		opts.Format = strings.ToLower(v)
+	}
+	if v := query.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, errors.New("Invalid size")
+		}
+		opts.Size = size
+	}
+	if v := query.Get("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, errors.New("Invalid margin")
+		}
+		opts.Margin = margin
+	}
+	if v := query.Get("level"); v != "" {
+		opts.Level = strings.ToUpper(v)
+	}
+	if v := query.Get("fg"); v != "" {
+		fg, err := qr.ParseHex(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.FG = fg
+	}
+	if v := query.Get("bg"); v != "" {
+		bg, err := qr.ParseHex(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.BG = bg
+	}
+	return opts, opts.Validate()
+}
@@ -10,47 +10,144 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/redis/go-redis/v9"
 
+	"url-shortener/internal/auth"
+	"url-shortener/internal/config"
 	"url-shortener/internal/middleware/ratelimit"
+	"url-shortener/static"
 )
 
-func New(db *sql.DB, rdb *redis.Client) http.Handler {
+func New(db *sql.DB, rdb *redis.Client, cfg *config.Config) http.Handler {
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 
 	// static files
-	router.Handle("/static/*",
-		http.StripPrefix("/static/", http.FileServer(http.Dir("./static"))),
-	)
+	assets := http.StripPrefix("/static/", http.FileServerFS(static.Files))
+	router.Get("/static/*", assets.ServeHTTP)
+	router.Head("/static/*", assets.ServeHTTP)
 
 	// UI fragments
 	router.Get("/ui/form", ui.RenderForm)
 
 	// index
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "static/index.html")
+		http.ServeFileFS(w, r, static.Files, "index.html")
 	})
 
+	// API documentation
+	router.Get("/api/openapi.json", OpenAPISpec)
+	router.Get("/api/docs", APIDocs)
+
 	// ---------------- RATE LIMITED GROUP -----------------
 	router.Group(func(sub chi.Router) {
 		sub.Use(ratelimit.Global(rdb, 50, time.Minute))
 
 		// shorten url
-		sub.With(ratelimit.PerIP(rdb, 10, time.Minute)).
+		sub.With(ratelimit.PerIP(rdb, 10, time.Minute), auth.Identify(db)).
 			Post("/shorten-url", func(w http.ResponseWriter, r *http.Request) {
-				ShortenURL(w, r, db, rdb)
+				ShortenURL(w, r, db, rdb, cfg)
+			})
+
+		// shorten many urls at once (API key required)
+		sub.With(ratelimit.PerIP(rdb, 10, time.Minute), auth.Identify(db)).
+			Post("/shorten-bulk", func(w http.ResponseWriter, r *http.Request) {
+				ShortenBulk(w, r, db, rdb, cfg)
+			})
+
+		// export the caller's links, and import links keeping their codes
+		sub.With(auth.Identify(db)).
+			Get("/export", func(w http.ResponseWriter, r *http.Request) {
+				ExportLinks(w, r, db)
+			})
+		sub.With(ratelimit.PerIP(rdb, 10, time.Minute), auth.Identify(db)).
+			Post("/import", func(w http.ResponseWriter, r *http.Request) {
+				ImportLinks(w, r, db, rdb, cfg)
+			})
+
+		// webhooks notified of the caller's link events (API key required)
+		sub.With(auth.Identify(db)).
+			Post("/webhooks", func(w http.ResponseWriter, r *http.Request) {
+				CreateWebhook(w, r, db, cfg)
+			})
+		sub.With(auth.Identify(db)).
+			Get("/webhooks", func(w http.ResponseWriter, r *http.Request) {
+				ListWebhooks(w, r, db)
+			})
+		sub.With(auth.Identify(db)).
+			Delete("/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+				DeleteWebhook(w, r, chi.URLParam(r, "id"), db)
+			})
+		sub.With(auth.Identify(db)).
+			Get("/webhooks/{id}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+				WebhookDeliveries(w, r, chi.URLParam(r, "id"), db)
+			})
+
+		// live clicks of the caller's links (API key required)
+		sub.With(auth.Identify(db)).
+			Get("/events", func(w http.ResponseWriter, r *http.Request) {
+				AccountEvents(w, r, db)
 			})
 
 		// track clicks
 		sub.Post("/track-clicks", func(w http.ResponseWriter, r *http.Request) {
-			TrackClicks(w, r, db)
+			TrackClicks(w, r, db, rdb)
 		})
 
-		// redirect
+		// stats of the caller's campaigns (API key required)
+		sub.With(auth.Identify(db)).
+			Get("/campaign-stats", func(w http.ResponseWriter, r *http.Request) {
+				CampaignStats(w, r, db)
+			})
+
+		// redirect; HEAD requests are redirected too, as bot clicks
 		sub.Get("/{code}", func(w http.ResponseWriter, r *http.Request) {
 			code := chi.URLParam(r, "code")
-			RedirectURL(w, r, code, db, rdb)
+			RedirectURL(w, r, code, "", db, rdb, cfg)
+		})
+		sub.Head("/{code}", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			RedirectURL(w, r, code, "", db, rdb, cfg)
 		})
 
+		// public info page, e.g. /abc123+ or /abc123/info
+		sub.Get("/{code}+", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			LinkInfo(w, r, code, db, rdb)
+		})
+		sub.Get("/{code}/info", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			LinkInfo(w, r, code, db, rdb)
+		})
+
+		// QR code of a short link
+		sub.Get("/{code}/qr", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			QRCode(w, r, code, db, rdb)
+		})
+
+		// live clicks of a short link; like /info, this path is never
+		// forwarded to the destination of a path-passthrough link
+		sub.Get("/{code}/events", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			LinkEvents(w, r, code, db)
+		})
+
+		// redirect with path passthrough, e.g. /abc123/docs/intro
+		sub.Get("/{code}/*", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			RedirectURL(w, r, code, extraPath(r, code), db, rdb, cfg)
+		})
+		sub.Head("/{code}/*", func(w http.ResponseWriter, r *http.Request) {
+			code := chi.URLParam(r, "code")
+			RedirectURL(w, r, code, extraPath(r, code), db, rdb, cfg)
+		})
+
+		// unlock password-protected link
+		sub.With(ratelimit.PerIPAndPath(rdb, 5, 15*time.Minute)).
+			Post("/{code}", func(w http.ResponseWriter, r *http.Request) {
+				code := chi.URLParam(r, "code")
+				UnlockURL(w, r, code, db, rdb, cfg)
+			})
+
 		// preview
 		sub.Post("/preview-url", func(w http.ResponseWriter, r *http.Request) {
 			PreviewURL(w, r, db, rdb)
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/web/ui"
+)
+
+// datetimeLocalLayout is what <input type="datetime-local"> submits
+const datetimeLocalLayout = "2006-01-02T15:04"
+
+// serveInactiveLink handles visits outside a link's activation window:
+// the fallback destination if there is one, otherwise a "coming soon"
+// page before the window opens and 410 Gone after it closes
+func serveInactiveLink(w http.ResponseWriter, r *http.Request, link *links.Link, now time.Time) {
+	// The outcome changes once the window opens/closes
+	w.Header().Set("Cache-Control", "private, no-store, max-age=0")
+
+	if link.FallbackURL != "" {
+		http.Redirect(w, r, link.FallbackURL, http.StatusFound)
+		return
+	}
+
+	if link.NotBefore != nil && now.Before(*link.NotBefore) {
+		data := struct{ NotBefore string }{link.NotBefore.UTC().Format("Jan 2, 2006 15:04 MST")}
+		ui.Render(w, http.StatusOK, "coming-soon.html", data)
+		return
+	}
+
+	http.Error(w, "This link has expired", http.StatusGone)
+}
+
+// parseSchedule reads the optional activation window from the form
+func parseSchedule(r *http.Request, link *links.Link) error {
+	// Browser's offset from UTC in minutes, as given by getTimezoneOffset()
+	tzOffset, _ := strconv.Atoi(r.FormValue("tz_offset"))
+	loc := time.FixedZone("", -tzOffset*60)
+
+	var err error
+	if link.NotBefore, err = parseFormTime(r.FormValue("not_before"), loc); err != nil {
+		return errors.New("Invalid start time")
+	}
+	if link.NotAfter, err = parseFormTime(r.FormValue("not_after"), loc); err != nil {
+		return errors.New("Invalid end time")
+	}
+	if link.NotBefore != nil && link.NotAfter != nil && !link.NotAfter.After(*link.NotBefore) {
+		return errors.New("End time must be after start time")
+	}
+
+	if fallback := r.FormValue("fallback_url"); fallback != "" {
+		if link.NotBefore == nil && link.NotAfter == nil {
+			return errors.New("A fallback URL needs a start or end time")
+		}
+		if _, err := utils.ValidateLongURL(fallback); err != nil {
+			return errors.New("Invalid or unsafe fallback URL")
+		}
+		link.FallbackURL = fallback
+	}
+	return nil
+}
+
+// parseFormTime accepts RFC 3339 timestamps (API clients) as well as
+// datetime-local values, which are interpreted in loc
+func parseFormTime(v string, loc *time.Location) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		t, err = time.ParseInLocation(datetimeLocalLayout, v, loc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	t = t.UTC()
+	return &t, nil
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+// variantCookieTTL keeps returning visitors on the same variant
+// for the typical length of an experiment
+const variantCookieTTL = 30 * 24 * time.Hour
+
+const variantCookieName = "miniurl_variant"
+
+// parseVariants reads the variants field: one "<weight> <url>" pair
+// per line, e.g. "70 https://example.com/a". Variants are named
+// A, B, C, ... in the order given.
+func parseVariants(r *http.Request, link *links.Link) error {
+	for _, line := range strings.Split(r.FormValue("variants"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		weight, destination, _ := strings.Cut(line, " ")
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 1 {
+			return errors.New("Invalid variant: " + line)
+		}
+		destination = strings.TrimSpace(destination)
+		if _, err := utils.ValidateLongURL(destination); err != nil {
+			return errors.New("Invalid or unsafe variant URL: " + destination)
+		}
+
+		link.Variants = append(link.Variants, links.Variant{
+			Name:        links.VariantName(len(link.Variants)),
+			Destination: destination,
+			Weight:      w,
+		})
+	}
+
+	if len(link.Variants) == 1 {
+		return errors.New("A split link needs at least two variants")
+	}
+	return nil
+}
+
+// assignVariant returns the visitor's variant, reusing the one from
+// their cookie when it's still valid so that assignment is sticky
+func assignVariant(w http.ResponseWriter, r *http.Request, code string, link *links.Link) *links.Variant {
+	if cookie, err := r.Cookie(variantCookieName); err == nil {
+		if variant := link.VariantNamed(cookie.Value); variant != nil {
+			return variant
+		}
+	}
+
+	variant := link.PickVariant()
+	if variant == nil {
+		return nil
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookieName,
+		Value:    variant.Name,
+		Path:     "/" + code,
+		MaxAge:   int(variantCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return variant
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"url-shortener/internal/geoip"
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+// parseTargeting reads optional per-country and per-platform
+// destinations from the form. Country rules are evaluated first,
+// then platform rules. The link's own URL remains the default for
+// visitors matching no rule.
+func parseTargeting(r *http.Request, link *links.Link) error {
+	if err := parseGeoRules(r, link); err != nil {
+		return err
+	}
+
+	// e.g. target_ios=https://apps.apple.com/...
+	for _, platform := range links.Platforms {
+		destination := r.FormValue("target_" + platform)
+		if destination == "" {
+			continue
+		}
+		if _, err := utils.ValidateLongURL(destination); err != nil {
+			return errors.New("Invalid or unsafe URL for " + platform + " visitors")
+		}
+		link.Rules = append(link.Rules, links.Rule{
+			Kind:        links.RulePlatform,
+			Value:       platform,
+			Destination: destination,
+		})
+	}
+	return nil
+}
+
+// parseGeoRules reads the geo_rules field: one "<country code> <url>"
+// (or "<country code>=<url>") pair per line, e.g. "DE https://example.de"
+func parseGeoRules(r *http.Request, link *links.Link) error {
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(r.FormValue("geo_rules"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// The country ends at the first space or '='; the destination
+		// is kept as is, '=' in its query included
+		i := strings.IndexAny(line, " =")
+		if i < 0 {
+			return errors.New("Invalid geo rule: " + line)
+		}
+		country := strings.ToUpper(line[:i])
+		destination := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[i+1:]), "="))
+		if !isCountryCode(country) || destination == "" {
+			return errors.New("Invalid geo rule: " + line)
+		}
+		if seen[country] {
+			return errors.New("Duplicate geo rule for " + country)
+		}
+		if _, err := utils.ValidateLongURL(destination); err != nil {
+			return errors.New("Invalid or unsafe URL for " + country + " visitors")
+		}
+
+		seen[country] = true
+		link.Rules = append(link.Rules, links.Rule{
+			Kind:        links.RuleCountry,
+			Value:       country,
+			Destination: destination,
+		})
+	}
+	return nil
+}
+
+// isCountryCode checks for an ISO 3166-1 alpha-2 shaped code
+func isCountryCode(s string) bool {
+	return len(s) == 2 && 'A' <= s[0] && s[0] <= 'Z' && 'A' <= s[1] && s[1] <= 'Z'
+}
+
+// visitorFrom collects what targeting rules are matched against
+func visitorFrom(r *http.Request) links.Visitor {
+	platform, mobile := utils.DetectPlatform(r)
+	return links.Visitor{
+		Platform: platform,
+		Mobile:   mobile,
+		Country:  geoip.Country(utils.GetIP(r)),
+	}
+}
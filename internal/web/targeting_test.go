@@ -0,0 +1,58 @@
+package web
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"url-shortener/internal/links"
+)
+
+// Destinations are IP literals, so that validating them needs no DNS
+func TestParseGeoRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		want    []links.Rule
+		wantErr bool
+	}{
+		{"space", "DE https://93.184.216.34", []links.Rule{{Kind: links.RuleCountry, Value: "DE", Destination: "https://93.184.216.34"}}, false},
+		{"equals", "de=https://93.184.216.34", []links.Rule{{Kind: links.RuleCountry, Value: "DE", Destination: "https://93.184.216.34"}}, false},
+		{"equals with spaces", "DE = https://93.184.216.34", []links.Rule{{Kind: links.RuleCountry, Value: "DE", Destination: "https://93.184.216.34"}}, false},
+		{"query after space", "DE https://93.184.216.34/?lang=de&x=1", []links.Rule{{Kind: links.RuleCountry, Value: "DE", Destination: "https://93.184.216.34/?lang=de&x=1"}}, false},
+		{"query after equals", "DE=https://93.184.216.34/?lang=de", []links.Rule{{Kind: links.RuleCountry, Value: "DE", Destination: "https://93.184.216.34/?lang=de"}}, false},
+		{"several lines", "DE https://93.184.216.34\n\n FR https://151.101.1.1/?lang=fr \n", []links.Rule{
+			{Kind: links.RuleCountry, Value: "DE", Destination: "https://93.184.216.34"},
+			{Kind: links.RuleCountry, Value: "FR", Destination: "https://151.101.1.1/?lang=fr"},
+		}, false},
+		{"no destination", "DE", nil, true},
+		{"empty destination", "DE=", nil, true},
+		{"not a country", "DEU https://93.184.216.34", nil, true},
+		{"duplicate", "DE https://93.184.216.34\nde https://151.101.1.1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"geo_rules": {tt.rules}}
+			r := httptest.NewRequest("POST", "/shorten", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			link := &links.Link{}
+			err := parseGeoRules(r, link)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(link.Rules) != len(tt.want) {
+				t.Fatalf("rules = %+v, want %+v", link.Rules, tt.want)
+			}
+			for i := range tt.want {
+				if link.Rules[i] != tt.want[i] {
+					t.Errorf("rule %d = %+v, want %+v", i, link.Rules[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
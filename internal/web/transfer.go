@@ -0,0 +1,235 @@
+package web
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/config"
+	"url-shortener/internal/links"
+	"url-shortener/internal/utils"
+)
+
+// exportColumns are the CSV columns of an export, which
+// ImportLinks accepts back
+var exportColumns = []string{
+	"code", "url", "created_at", "click_count", "last_visited_at",
+	"title", "description", "image_url",
+}
+
+// exportFlushEvery is how many rows are buffered before being sent
+const exportFlushEvery = 100
+
+// ExportLinks streams the caller's links with their stats and
+// metadata as CSV (default) or NDJSON (?format=ndjson)
+func ExportLinks(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	ctx := r.Context()
+
+	creator := auth.FromContext(ctx)
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	var write func(links.ExportRow) error
+	var flush func()
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="links.csv"`)
+
+		writer := csv.NewWriter(w)
+		writer.Write(exportColumns)
+		write = func(row links.ExportRow) error {
+			return writer.Write([]string{
+				row.Code, row.URL, formatExportTime(row.CreatedAt),
+				strconv.FormatInt(row.ClickCount, 10), formatExportTime(row.LastVisitedAt),
+				row.Title, row.Description, row.ImageURL,
+			})
+		}
+		flush = writer.Flush
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="links.ndjson"`)
+
+		encoder := json.NewEncoder(w)
+		write = func(row links.ExportRow) error {
+			return encoder.Encode(row)
+		}
+		flush = func() {}
+	default:
+		http.Error(w, "Invalid format: must be one of csv, ndjson", http.StatusBadRequest)
+		return
+	}
+
+	// Send rows as they are read instead of building the whole export
+	flusher, _ := w.(http.Flusher)
+	n := 0
+	err := links.Export(ctx, db, creator.ID, func(row links.ExportRow) error {
+		if err := write(row); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	flush()
+	if err != nil {
+		// Headers are already sent, the export ends up truncated
+		log.Println("Export error:", err)
+	}
+}
+
+// ImportLinks recreates links exported from this or another shortener,
+// keeping their codes, creation dates and click stats. Rows take the
+// export's columns plus any field of the shorten form, and are imported
+// in a single transaction.
+func ImportLinks(w http.ResponseWriter, r *http.Request, db *sql.DB, rdb *redis.Client, cfg *config.Config) {
+	ctx := r.Context()
+
+	rows, ok := readBulkRows(w, r, cfg)
+	if !ok {
+		// Error response already written
+		return
+	}
+
+	var (
+		results = make([]bulkResult, len(rows))
+		batch   []*links.Imported
+
+		// Result row -> index in batch, -1 if not imported
+		imported = make([]int, len(rows))
+		// Codes used so far by this upload
+		codes = make(map[string]bool)
+	)
+	for i, values := range rows {
+		result := &results[i]
+		result.Row = i + 1
+		result.URL = values.Get("url")
+		imported[i] = -1
+
+		link, err := validateShortenRequest(rowRequest(r, values))
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		dedupKey, err := utils.NormalizeURL(link.LongURL, cfg.Normalize)
+		if err != nil {
+			result.Error = "Invalid or unsafe URL"
+			continue
+		}
+		item, err := parseImportRow(values, link, dedupKey)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		owner, err := links.CodeOwner(ctx, db, item.Code)
+		if err != nil {
+			result.Error = "Database error"
+			continue
+		}
+		reserved, err := links.CodeReserved(ctx, db, item.Code)
+		if err != nil {
+			result.Error = "Database error"
+			continue
+		}
+		// Importing the same export twice finds its links already there
+		if owner == link.LongURL && !codes[item.Code] {
+			result.Code, result.Existing = item.Code, true
+			result.ShortURL = shortURLFor(r, item.Code)
+			codes[item.Code] = true
+			continue
+		}
+		if owner != "" || codes[item.Code] {
+			result.Error = "Code already in use"
+			continue
+		}
+		if reserved {
+			result.Error = "Code is reserved for links created here"
+			continue
+		}
+		codes[item.Code] = true
+
+		imported[i] = len(batch)
+		batch = append(batch, item)
+	}
+
+	// Insert all links at once
+	if len(batch) > 0 {
+		if err := links.ImportMany(ctx, db, batch); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		for _, item := range batch {
+			announceLink(ctx, rdb, item.Code, item.Link, item.DedupKey)
+		}
+	}
+
+	for i := range results {
+		if j := imported[i]; j >= 0 {
+			results[i].Code = batch[j].Code
+			results[i].ShortURL = shortURLFor(r, batch[j].Code)
+		}
+	}
+	writeBulkResults(w, results)
+}
+
+/**** Helper Methods below ****/
+
+// parseImportRow reads the code and stats of an imported link
+func parseImportRow(values url.Values, link *links.Link, dedupKey string) (*links.Imported, error) {
+	item := &links.Imported{
+		Link:     link,
+		DedupKey: dedupKey,
+		Code:     strings.TrimSpace(values.Get("code")),
+	}
+	if err := links.ValidateImportedCode(item.Code); err != nil {
+		return nil, err
+	}
+
+	if v := values.Get("created_at"); v != "" {
+		createdAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("Invalid created_at: must be an RFC 3339 time")
+		}
+		link.CreatedAt = createdAt.UTC()
+	}
+	if v := values.Get("click_count"); v != "" {
+		clickCount, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || clickCount < 0 {
+			return nil, errors.New("Invalid click_count: must be zero or more")
+		}
+		item.ClickCount = clickCount
+	}
+	if v := values.Get("last_visited_at"); v != "" {
+		lastVisited, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("Invalid last_visited_at: must be an RFC 3339 time")
+		}
+		item.LastVisitedAt = &lastVisited
+	}
+	return item, nil
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
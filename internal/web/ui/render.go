@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+
+	"url-shortener/static"
+)
+
+// contentSecurityPolicy only allows resources from this origin and
+// the CDNs that index.html already depends on. Images may come from
+// anywhere over HTTPS to show destinations' preview images.
+const contentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' https://unpkg.com https://cdn.tailwindcss.com; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: https:; " +
+	"object-src 'none'; " +
+	"base-uri 'none'; " +
+	"form-action 'self'; " +
+	"frame-ancestors 'none'"
+
+// partials holds every HTMX fragment under static/partials and every
+// full page under static/pages, keyed by file name (e.g. "short-url.html")
+var partials = template.Must(
+	template.ParseFS(static.Files, "partials/*.html", "pages/*.html"),
+)
+
+// Render executes the named partial or page with data and writes it using
+// the given status code. All user-supplied values are escaped by
+// html/template, so handlers must never build HTML by hand.
+func Render(w http.ResponseWriter, status int, name string, data any) {
+	// Render into a buffer first so a template error doesn't
+	// leave a half-written fragment behind
+	var buf bytes.Buffer
+	if err := partials.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	SetSecurityHeaders(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// SetSecurityHeaders adds the CSP and other hardening
+// headers to every HTML response
+func SetSecurityHeaders(w http.ResponseWriter) {
+	h := w.Header()
+	h.Set("Content-Security-Policy", contentSecurityPolicy)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+}
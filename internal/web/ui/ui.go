@@ -1,14 +1,9 @@
 package ui
 
 import (
-	"html/template"
 	"net/http"
 )
 
-var formTmpl = template.Must(
-	template.ParseFiles("static/partials/url-form.html"),
-)
-
 func RenderForm(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Label       string
@@ -31,6 +26,5 @@ func RenderForm(w http.ResponseWriter, r *http.Request) {
 		data.Endpoint = "/shorten-url"
 	}
 
-	w.Header().Set("Content-Type", "text/html")
-	_ = formTmpl.Execute(w, data)
+	Render(w, http.StatusOK, "url-form.html", data)
 }
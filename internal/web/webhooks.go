@@ -0,0 +1,163 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/config"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/webhooks"
+)
+
+// maxWebhooks caps the webhooks of a single API key
+const maxWebhooks = 10
+
+// CreateWebhook subscribes a URL to events of the caller's links. The
+// response holds the secret deliveries are signed with; it isn't shown
+// again.
+func CreateWebhook(w http.ResponseWriter, r *http.Request, db *sql.DB, cfg *config.Config) {
+	ctx := r.Context()
+
+	creator := auth.FromContext(ctx)
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	target, err := validateWebhookURL(strings.TrimSpace(r.FormValue("url")), cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	types, err := webhooks.ParseEvents(strings.Join(r.Form["events"], ","))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := webhooks.List(ctx, db, creator.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) >= maxWebhooks {
+		http.Error(w, fmt.Sprintf("Too many webhooks: the limit is %d", maxWebhooks), http.StatusBadRequest)
+		return
+	}
+
+	hook, err := webhooks.Create(ctx, db, creator.ID, target, types)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+// ListWebhooks lists the caller's webhooks, without their secrets
+func ListWebhooks(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	creator := auth.FromContext(r.Context())
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	hooks, err := webhooks.List(r.Context(), db, creator.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, hooks)
+}
+
+// DeleteWebhook unsubscribes one of the caller's webhooks
+// and drops its delivery log
+func DeleteWebhook(w http.ResponseWriter, r *http.Request, id string, db *sql.DB) {
+	creator := auth.FromContext(r.Context())
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+	webhookID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		http.Error(w, "Webhook not found!", http.StatusNotFound)
+		return
+	}
+
+	err = webhooks.Delete(r.Context(), db, creator.ID, webhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Webhook not found!", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WebhookDeliveries returns the latest deliveries of one of the caller's
+// webhooks. "?status=dead" lists the deliveries that ran out of retries.
+func WebhookDeliveries(w http.ResponseWriter, r *http.Request, id string, db *sql.DB) {
+	ctx := r.Context()
+
+	creator := auth.FromContext(ctx)
+	if creator == nil {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+	webhookID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		http.Error(w, "Webhook not found!", http.StatusNotFound)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", webhooks.StatusPending, webhooks.StatusDelivered, webhooks.StatusDead:
+	default:
+		http.Error(w, "Invalid status: must be one of pending, delivered, dead", http.StatusBadRequest)
+		return
+	}
+
+	owned, err := webhooks.Owns(ctx, db, creator.ID, webhookID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !owned {
+		http.Error(w, "Webhook not found!", http.StatusNotFound)
+		return
+	}
+
+	deliveries, err := webhooks.Deliveries(ctx, db, webhookID, status, 100)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+/**** Helper Methods below ****/
+
+// validateWebhookURL only accepts receivers on public addresses,
+// unless the server is configured to allow internal ones
+func validateWebhookURL(rawURL string, cfg *config.Config) (string, error) {
+	if rawURL == "" {
+		return "", errors.New("URL required")
+	}
+	if cfg.WebhookAllowPrivate {
+		u, err := url.ParseRequestURI(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", errors.New("Invalid URL")
+		}
+		return rawURL, nil
+	}
+	return utils.ValidateLongURL(rawURL)
+}
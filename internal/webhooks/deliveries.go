@@ -0,0 +1,420 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"url-shortener/internal/utils"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+
+	// StatusDead marks deliveries that ran out of retries. They are
+	// kept in the delivery log as the dead-letter record.
+	StatusDead = "dead"
+)
+
+// Limits applied to deliveries
+const (
+	deliveryTimeout = 10 * time.Second
+	maxBackoff      = 6 * time.Hour
+	batchSize       = 50
+	maxErrorLen     = 300
+	pruneInterval   = time.Hour
+)
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Code      string    `json:"code"`
+
+	// URL is the link's destination, unset once it is deleted
+	URL string `json:"url,omitempty"`
+
+	// Click is set for link.clicked events
+	Click *Click `json:"click,omitempty"`
+}
+
+// Click describes a visit, as recorded in click stats
+type Click struct {
+	Country string `json:"country,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+// Delivery is one event sent (or to be sent) to a webhook
+type Delivery struct {
+	ID       int64           `json:"id"`
+	Event    string          `json:"event"`
+	Payload  json.RawMessage `json:"payload"`
+	Status   string          `json:"status"`
+	Attempts int             `json:"attempts"`
+
+	// Outcome of the last attempt
+	ResponseStatus int    `json:"response_status,omitempty"`
+	Error          string `json:"error,omitempty"`
+
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Enqueue records a delivery of payload for every webhook of the API
+// key subscribed to its event type. Keys that were revoked get nothing.
+func Enqueue(ctx context.Context, db *sql.DB, apiKeyID int64, payload Payload) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT webhooks.id, webhooks.events FROM webhooks
+		JOIN api_keys ON api_keys.id = webhooks.api_key_id
+		WHERE webhooks.api_key_id = ? AND api_keys.revoked_at IS NULL`, apiKeyID)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var (
+			id    int64
+			types string
+		)
+		if err := rows.Scan(&id, &types); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if subscribed(types, payload.Type) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	for _, id := range ids {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (webhook_id, event, payload, status, created_at, next_attempt_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			id, payload.Type, string(body), StatusPending, now, now,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// Deliveries returns the webhook's latest deliveries, newest first,
+// optionally only those with the given status
+func Deliveries(ctx context.Context, db *sql.DB, webhookID int64, status string, limit int) ([]Delivery, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, event, payload, status, attempts, response_status, error,
+		       created_at, next_attempt_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = ? AND (? = '' OR status = ?)
+		ORDER BY id DESC
+		LIMIT ?`,
+		webhookID, status, status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var (
+			d                        Delivery
+			payload                  string
+			responseStatus           sql.NullInt64
+			lastError                sql.NullString
+			nextAttempt, deliveredAt sql.NullTime
+		)
+		err := rows.Scan(&d.ID, &d.Event, &payload, &d.Status, &d.Attempts, &responseStatus, &lastError,
+			&d.CreatedAt, &nextAttempt, &deliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = json.RawMessage(payload)
+		d.ResponseStatus = int(responseStatus.Int64)
+		d.Error = lastError.String
+		if nextAttempt.Valid {
+			d.NextAttemptAt = &nextAttempt.Time
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Sign returns the signature sent in the X-Miniurl-Signature header:
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher sends due deliveries, retrying failed ones with
+// exponential backoff until they run out of attempts
+type Dispatcher struct {
+	db     *sql.DB
+	client *http.Client
+
+	maxAttempts int
+	retryBase   time.Duration
+	retention   time.Duration
+}
+
+// NewDispatcher returns a Dispatcher that makes at most maxAttempts per
+// delivery, waiting retryBase, then twice as long after every failure.
+// Delivered deliveries are pruned once older than retention, unless it
+// is zero. Unless allowPrivate is set, it refuses to connect to internal
+// addresses.
+func NewDispatcher(db *sql.DB, maxAttempts int, retryBase, retention time.Duration, allowPrivate bool) *Dispatcher {
+	transport := &http.Transport{
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+	}
+	if !allowPrivate {
+		transport.DialContext = utils.PublicDialer(5 * time.Second).DialContext
+	}
+
+	return &Dispatcher{
+		db: db,
+		client: &http.Client{
+			Timeout:   deliveryTimeout,
+			Transport: transport,
+			// Receivers must answer themselves; a redirect counts as a failure
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: maxAttempts,
+		retryBase:   retryBase,
+		retention:   retention,
+	}
+}
+
+// Run sends due deliveries every interval, and prunes old ones every
+// hour, until ctx is done
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	d.prune(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.DeliverDue(ctx); err != nil {
+				log.Println("Webhook delivery error:", err)
+			}
+		case <-pruneTicker.C:
+			d.prune(ctx)
+		}
+	}
+}
+
+// DeliverDue makes one attempt at every delivery that is due.
+// Deliveries are claimed first, so several workers can share the load.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	now := time.Now().UTC()
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT webhook_deliveries.id, webhook_deliveries.event, webhook_deliveries.payload,
+		       webhook_deliveries.attempts, webhooks.url, webhooks.secret
+		FROM webhook_deliveries
+		JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+		WHERE webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?
+		ORDER BY webhook_deliveries.next_attempt_at
+		LIMIT ?`,
+		StatusPending, now, batchSize,
+	)
+	if err != nil {
+		return err
+	}
+	var due []dueDelivery
+	for rows.Next() {
+		var item dueDelivery
+		if err := rows.Scan(&item.id, &item.event, &item.payload, &item.attempts, &item.url, &item.secret); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, item := range due {
+		// Push the next attempt back while this one is in flight.
+		// Attempts only grow, so losing the race leaves no row changed.
+		res, err := d.db.ExecContext(ctx, `
+			UPDATE webhook_deliveries SET next_attempt_at = ?
+			WHERE id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ?`,
+			now.Add(2*deliveryTimeout), item.id, StatusPending, item.attempts, now,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, item)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Prune deletes the deliveries delivered longer than the retention
+// ago and returns how many. Pending deliveries and dead letters stay.
+func (d *Dispatcher) Prune(ctx context.Context) (int64, error) {
+	if d.retention <= 0 {
+		return 0, nil
+	}
+	res, err := d.db.ExecContext(ctx,
+		"DELETE FROM webhook_deliveries WHERE status = ? AND delivered_at < ?",
+		StatusDelivered, time.Now().UTC().Add(-d.retention),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+/**** Helper Methods below ****/
+
+// prune runs Prune, logging the outcome
+func (d *Dispatcher) prune(ctx context.Context) {
+	n, err := d.Prune(ctx)
+	if err != nil {
+		log.Println("Webhook delivery pruning error:", err)
+	} else if n > 0 {
+		log.Printf("Pruned %d webhook deliveries", n)
+	}
+}
+
+type dueDelivery struct {
+	id       int64
+	event    string
+	payload  string
+	attempts int
+	url      string
+	secret   string
+}
+
+// deliver makes one attempt and records its outcome
+func (d *Dispatcher) deliver(ctx context.Context, item dueDelivery) {
+	status, err := d.send(ctx, item)
+
+	var (
+		attempts    = item.attempts + 1
+		now         = time.Now().UTC()
+		state       = StatusPending
+		nextAttempt sql.NullTime
+		deliveredAt sql.NullTime
+		lastError   sql.NullString
+	)
+	switch {
+	case err == nil:
+		state = StatusDelivered
+		deliveredAt = sql.NullTime{Time: now, Valid: true}
+	case attempts >= d.maxAttempts:
+		state = StatusDead
+		lastError = sql.NullString{String: truncate(err.Error(), maxErrorLen), Valid: true}
+	default:
+		nextAttempt = sql.NullTime{Time: now.Add(d.backoff(attempts)), Valid: true}
+		lastError = sql.NullString{String: truncate(err.Error(), maxErrorLen), Valid: true}
+	}
+
+	responseStatus := sql.NullInt64{Int64: int64(status), Valid: status != 0}
+	_, dbErr := d.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?`,
+		state, attempts, responseStatus, lastError, nextAttempt, deliveredAt, item.id,
+	)
+	if dbErr != nil {
+		log.Println("Webhook delivery update error:", dbErr)
+	}
+	if state == StatusDead {
+		log.Println("Webhook delivery", item.id, "is dead after", attempts, "attempts:", err)
+	}
+}
+
+// send posts the signed payload and returns the response status, if any.
+// Anything but a 2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, item dueDelivery) (int, error) {
+	body := []byte(item.payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, item.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "miniurl-webhooks/1.0")
+	req.Header.Set("X-Miniurl-Event", item.event)
+	req.Header.Set("X-Miniurl-Delivery", strconv.FormatInt(item.id, 10))
+	req.Header.Set("X-Miniurl-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Miniurl-Signature", Sign(item.secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns how long to wait after the given number of attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.retryBase
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,370 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"url-shortener/internal/db"
+	"url-shortener/internal/events"
+)
+
+// receiver records the deliveries it gets and answers with status
+type receiver struct {
+	*httptest.Server
+	hits atomic.Int32
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	t.Helper()
+	rcv := &receiver{}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		rcv.requests = append(rcv.requests, r)
+		rcv.bodies = append(rcv.bodies, body)
+		rcv.mu.Unlock()
+		rcv.hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+// setup returns a database with a webhook subscribed to url,
+// and one delivery queued for it
+func setup(t *testing.T, url string) (*sql.DB, *Webhook) {
+	t.Helper()
+	ctx := context.Background()
+
+	conn := db.InitSQLite(filepath.Join(t.TempDir(), "urls.db"))
+	t.Cleanup(func() { conn.Close() })
+
+	res, err := conn.Exec("INSERT INTO api_keys (name, key_hash, created_at) VALUES ('test', 'hash', ?)", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID, _ := res.LastInsertId()
+
+	hook, err := Create(ctx, conn, keyID, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Enqueue(ctx, conn, keyID, Payload{Type: events.LinkCreated, CreatedAt: time.Now().UTC(), Code: "6LAzd"}); err != nil || n != 1 {
+		t.Fatalf("Enqueue = %d, %v, want 1 delivery", n, err)
+	}
+	return conn, hook
+}
+
+func lastDelivery(t *testing.T, conn *sql.DB, hook *Webhook) Delivery {
+	t.Helper()
+	deliveries, err := Deliveries(context.Background(), conn, hook.ID, "", 1)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("Deliveries = %v, %v", deliveries, err)
+	}
+	return deliveries[0]
+}
+
+// makeDue moves the webhook's pending deliveries' next attempt to now
+func makeDue(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	_, err := conn.Exec("UPDATE webhook_deliveries SET next_attempt_at = ? WHERE status = ?",
+		time.Now().UTC().Add(-time.Second), StatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	got := Sign("whsec_test", 1700000000, []byte(`{"type":"link.created"}`))
+	want := "sha256=a8bc0e81c6d690227723d3996e08475a00b6e8824f86e0ac87eff09a0215ef05"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("other", 1700000000, []byte(`{"type":"link.created"}`)) == want {
+		t.Error("signature doesn't depend on the secret")
+	}
+	if Sign("whsec_test", 1700000001, []byte(`{"type":"link.created"}`)) == want {
+		t.Error("signature doesn't depend on the timestamp")
+	}
+}
+
+func TestDeliverDue(t *testing.T) {
+	rcv := newReceiver(t, http.StatusNoContent)
+	conn, hook := setup(t, rcv.URL)
+
+	if err := NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if rcv.hits.Load() != 1 {
+		t.Fatalf("receiver got %d requests, want 1", rcv.hits.Load())
+	}
+
+	req, body := rcv.requests[0], rcv.bodies[0]
+	if req.Header.Get("X-Miniurl-Event") != events.LinkCreated {
+		t.Errorf("X-Miniurl-Event = %q", req.Header.Get("X-Miniurl-Event"))
+	}
+	timestamp, err := strconv.ParseInt(req.Header.Get("X-Miniurl-Timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("X-Miniurl-Timestamp: %v", err)
+	}
+	if got, want := req.Header.Get("X-Miniurl-Signature"), Sign(hook.Secret, timestamp, body); got != want {
+		t.Errorf("X-Miniurl-Signature = %s, want %s", got, want)
+	}
+
+	d := lastDelivery(t, conn, hook)
+	if d.Status != StatusDelivered || d.Attempts != 1 || d.ResponseStatus != http.StatusNoContent ||
+		d.DeliveredAt == nil || d.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want delivered on the first attempt", d)
+	}
+
+	// Delivered deliveries aren't sent again
+	if err := NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if rcv.hits.Load() != 1 {
+		t.Errorf("receiver got %d requests, want 1", rcv.hits.Load())
+	}
+}
+
+func TestDeliveryRetriesThenDies(t *testing.T) {
+	rcv := newReceiver(t, http.StatusInternalServerError)
+	conn, hook := setup(t, rcv.URL)
+	ctx := context.Background()
+	dispatcher := NewDispatcher(conn, 3, time.Minute, 0, true)
+
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		start := time.Now().UTC()
+		if err := dispatcher.DeliverDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		d := lastDelivery(t, conn, hook)
+		if d.Status != StatusPending || d.Attempts != attempt+1 || d.ResponseStatus != http.StatusInternalServerError || d.Error == "" {
+			t.Fatalf("after attempt %d: delivery = %+v, want pending with the error", attempt+1, d)
+		}
+		if d.NextAttemptAt == nil || d.NextAttemptAt.Before(start.Add(wait)) || d.NextAttemptAt.After(time.Now().Add(wait)) {
+			t.Errorf("after attempt %d: next attempt at %v, want in %v", attempt+1, d.NextAttemptAt, wait)
+		}
+
+		// Not due yet
+		if err := dispatcher.DeliverDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if n := rcv.hits.Load(); n != int32(attempt+1) {
+			t.Fatalf("receiver got %d requests before the retry was due, want %d", n, attempt+1)
+		}
+		makeDue(t, conn)
+	}
+
+	// The third attempt is the last one
+	if err := dispatcher.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d := lastDelivery(t, conn, hook)
+	if d.Status != StatusDead || d.Attempts != 3 || d.NextAttemptAt != nil || d.Error == "" {
+		t.Errorf("delivery = %+v, want dead after 3 attempts", d)
+	}
+	if dead, _ := Deliveries(ctx, conn, hook.ID, StatusDead, 10); len(dead) != 1 {
+		t.Errorf("%d dead letters, want 1", len(dead))
+	}
+
+	makeDue(t, conn)
+	if err := dispatcher.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rcv.hits.Load() != 3 {
+		t.Errorf("receiver got %d requests, want 3", rcv.hits.Load())
+	}
+}
+
+func TestRedirectIsAFailure(t *testing.T) {
+	rcv := newReceiver(t, http.StatusFound)
+	conn, hook := setup(t, rcv.URL)
+
+	if err := NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := lastDelivery(t, conn, hook); d.Status != StatusPending || d.ResponseStatus != http.StatusFound {
+		t.Errorf("delivery = %+v, want a failed attempt", d)
+	}
+}
+
+func TestInternalReceiversRefused(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	conn, hook := setup(t, rcv.URL)
+
+	if err := NewDispatcher(conn, 3, time.Minute, 0, false).DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if rcv.hits.Load() != 0 {
+		t.Errorf("receiver got %d requests, want none", rcv.hits.Load())
+	}
+	if d := lastDelivery(t, conn, hook); d.Status != StatusPending || d.Error == "" {
+		t.Errorf("delivery = %+v, want a failed attempt", d)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{retryBase: 30 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, maxBackoff},
+		{100, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestDeliverDueClaims checks that a delivery in flight isn't sent
+// again by another worker
+func TestDeliverDueClaims(t *testing.T) {
+	var (
+		hits     atomic.Int32
+		received = make(chan struct{})
+		release  = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			close(received)
+		}
+		<-release
+	}))
+	defer srv.Close()
+	conn, hook := setup(t, srv.URL)
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		done <- NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(ctx)
+	}()
+	<-received
+
+	// A second worker finds nothing due while the first one waits
+	if err := NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if hits.Load() != 1 {
+		t.Errorf("receiver got %d requests, want 1", hits.Load())
+	}
+	if d := lastDelivery(t, conn, hook); d.Status != StatusDelivered || d.Attempts != 1 {
+		t.Errorf("delivery = %+v, want delivered once", d)
+	}
+}
+
+// TestConcurrentDispatchers checks that workers sharing the queue
+// send every delivery exactly once
+func TestConcurrentDispatchers(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	conn, hook := setup(t, rcv.URL)
+	ctx := context.Background()
+
+	const queued = 20
+	for range queued - 1 {
+		if _, err := Enqueue(ctx, conn, 1, Payload{Type: events.LinkClicked, CreatedAt: time.Now().UTC(), Code: "6LAzd"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if rcv.hits.Load() != queued {
+		t.Errorf("receiver got %d requests, want %d", rcv.hits.Load(), queued)
+	}
+	delivered, err := Deliveries(ctx, conn, hook.ID, StatusDelivered, 100)
+	if err != nil || len(delivered) != queued {
+		t.Errorf("%d deliveries delivered (%v), want %d", len(delivered), err, queued)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	rcv := newReceiver(t, http.StatusOK)
+	conn, hook := setup(t, rcv.URL)
+	ctx := context.Background()
+
+	// One delivery delivered long ago, one just now, one pending
+	// and one dead letter
+	if err := NewDispatcher(conn, 3, time.Minute, 0, true).DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	if _, err := conn.Exec("UPDATE webhook_deliveries SET delivered_at = ?, created_at = ?", old, old); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := Enqueue(ctx, conn, 1, Payload{Type: events.LinkClicked, CreatedAt: time.Now().UTC(), Code: "6LAzd"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := conn.Exec(`UPDATE webhook_deliveries SET status = ?, delivered_at = ? WHERE id = (SELECT MAX(id) - 2 FROM webhook_deliveries)`,
+		StatusDelivered, time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`UPDATE webhook_deliveries SET status = ?, next_attempt_at = NULL WHERE id = (SELECT MAX(id) FROM webhook_deliveries)`,
+		StatusDead)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a retention, everything is kept
+	if n, err := NewDispatcher(conn, 3, time.Minute, 0, true).Prune(ctx); err != nil || n != 0 {
+		t.Errorf("Prune without retention = %d, %v, want nothing pruned", n, err)
+	}
+
+	n, err := NewDispatcher(conn, 3, time.Minute, 24*time.Hour, true).Prune(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Prune = %d, %v, want 1 delivery pruned", n, err)
+	}
+	left := make(map[string]int)
+	deliveries, err := Deliveries(ctx, conn, hook.ID, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range deliveries {
+		left[d.Status]++
+		if d.DeliveredAt != nil && d.DeliveredAt.Before(time.Now().Add(-time.Hour)) {
+			t.Errorf("delivery %d delivered at %v is still there", d.ID, d.DeliveredAt)
+		}
+	}
+	if left[StatusDelivered] != 1 || left[StatusPending] != 1 || left[StatusDead] != 1 {
+		t.Errorf("left %v, want one recent delivered, one pending and one dead", left)
+	}
+}
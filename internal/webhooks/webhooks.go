@@ -0,0 +1,153 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"url-shortener/internal/events"
+)
+
+// Events lists the event types webhooks can subscribe to
+var Events = []string{
+	events.LinkCreated,
+	events.LinkDisabled,
+	events.LinkEnabled,
+	events.LinkDeleted,
+	events.LinkClicked,
+}
+
+// Webhook is an API key's subscription to the events of its links
+type Webhook struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+
+	// Events the webhook is sent, all of them if empty
+	Events []string `json:"events"`
+
+	// Secret signs deliveries. Only returned when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ParseEvents reads a comma-separated list of event types
+func ParseEvents(v string) ([]string, error) {
+	var types []string
+	for _, eventType := range strings.Split(v, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		if !slices.Contains(Events, eventType) {
+			return nil, errors.New("Unknown event: " + eventType)
+		}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
+
+// Create subscribes url to the given events of the API key's links
+func Create(ctx context.Context, db *sql.DB, apiKeyID int64, url string, types []string) (*Webhook, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	hook := &Webhook{
+		URL:       url,
+		Events:    types,
+		Secret:    "whsec_" + hex.EncodeToString(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	res, err := db.ExecContext(ctx,
+		"INSERT INTO webhooks (api_key_id, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?)",
+		apiKeyID, url, hook.Secret, strings.Join(types, ","), hook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	hook.ID, err = res.LastInsertId()
+	return hook, err
+}
+
+// List returns the API key's webhooks, without their secrets
+func List(ctx context.Context, db *sql.DB, apiKeyID int64) ([]Webhook, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, url, events, created_at FROM webhooks WHERE api_key_id = ? ORDER BY id", apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var (
+			hook  Webhook
+			types string
+		)
+		if err := rows.Scan(&hook.ID, &hook.URL, &types, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		hook.Events = splitEvents(types)
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// Delete removes the API key's webhook and its delivery log.
+// Returns sql.ErrNoRows if the key has no such webhook.
+func Delete(ctx context.Context, db *sql.DB, apiKeyID, id int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ? AND api_key_id = ?", id, apiKeyID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	// Foreign keys aren't enforced, so ON DELETE CASCADE doesn't apply
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Owns reports whether the webhook belongs to the API key
+func Owns(ctx context.Context, db *sql.DB, apiKeyID, id int64) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = ? AND api_key_id = ?)", id, apiKeyID).
+		Scan(&exists)
+	return exists, err
+}
+
+/**** Helper Methods below ****/
+
+func splitEvents(types string) []string {
+	if types == "" {
+		return []string{}
+	}
+	return strings.Split(types, ",")
+}
+
+// subscribed reports whether a webhook subscribed to types gets eventType
+func subscribed(types string, eventType string) bool {
+	return types == "" || slices.Contains(strings.Split(types, ","), eventType)
+}
@@ -0,0 +1,4 @@
+// Package miniurlv1 holds the gRPC API served on GRPC_ADDR
+package miniurlv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative miniurl/v1/shortener.proto
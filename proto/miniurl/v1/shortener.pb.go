@@ -0,0 +1,1436 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v5.29.3
+// source: miniurl/v1/shortener.proto
+
+package miniurlv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// LinkOptions mirrors the fields of the shorten form. Only url is
+// required; unset fields leave an option unset.
+type LinkOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// 301, 302, 307 or 308; the server's default if unset
+	RedirectType int32 `protobuf:"varint,2,opt,name=redirect_type,json=redirectType,proto3" json:"redirect_type,omitempty"`
+	// Expires the link after this many clicks
+	MaxClicks int64 `protobuf:"varint,3,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// Bound when the link can be followed; fallback_url is used outside
+	NotBefore   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	FallbackUrl string                 `protobuf:"bytes,6,opt,name=fallback_url,json=fallbackUrl,proto3" json:"fallback_url,omitempty"`
+	// Platform (ios, android, windows, macos, linux, mobile, desktop)
+	// to destination
+	Targets map[string]string `protobuf:"bytes,7,rep,name=targets,proto3" json:"targets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Routes visitors by country, in order
+	GeoRules []*GeoRule `protobuf:"bytes,8,rep,name=geo_rules,json=geoRules,proto3" json:"geo_rules,omitempty"`
+	// Splits visitors across weighted destinations
+	Variants []*Variant `protobuf:"bytes,9,rep,name=variants,proto3" json:"variants,omitempty"`
+	// Forward the visitor's query string and extra path to the destination
+	PassQuery bool   `protobuf:"varint,10,opt,name=pass_query,json=passQuery,proto3" json:"pass_query,omitempty"`
+	PassPath  bool   `protobuf:"varint,11,opt,name=pass_path,json=passPath,proto3" json:"pass_path,omitempty"`
+	Password  string `protobuf:"bytes,12,opt,name=password,proto3" json:"password,omitempty"`
+	// "always", "never" (trusted API keys only) or empty for the
+	// server-wide setting
+	Interstitial  string    `protobuf:"bytes,13,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
+	Campaign      *Campaign `protobuf:"bytes,14,opt,name=campaign,proto3" json:"campaign,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkOptions) Reset() {
+	*x = LinkOptions{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkOptions) ProtoMessage() {}
+
+func (x *LinkOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkOptions.ProtoReflect.Descriptor instead.
+func (*LinkOptions) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LinkOptions) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *LinkOptions) GetRedirectType() int32 {
+	if x != nil {
+		return x.RedirectType
+	}
+	return 0
+}
+
+func (x *LinkOptions) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *LinkOptions) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *LinkOptions) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+func (x *LinkOptions) GetFallbackUrl() string {
+	if x != nil {
+		return x.FallbackUrl
+	}
+	return ""
+}
+
+func (x *LinkOptions) GetTargets() map[string]string {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *LinkOptions) GetGeoRules() []*GeoRule {
+	if x != nil {
+		return x.GeoRules
+	}
+	return nil
+}
+
+func (x *LinkOptions) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *LinkOptions) GetPassQuery() bool {
+	if x != nil {
+		return x.PassQuery
+	}
+	return false
+}
+
+func (x *LinkOptions) GetPassPath() bool {
+	if x != nil {
+		return x.PassPath
+	}
+	return false
+}
+
+func (x *LinkOptions) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *LinkOptions) GetInterstitial() string {
+	if x != nil {
+		return x.Interstitial
+	}
+	return ""
+}
+
+func (x *LinkOptions) GetCampaign() *Campaign {
+	if x != nil {
+		return x.Campaign
+	}
+	return nil
+}
+
+// GeoRule sends visitors from country (ISO code, e.g. "DE") to url
+type GeoRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Country       string                 `protobuf:"bytes,1,opt,name=country,proto3" json:"country,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeoRule) Reset() {
+	*x = GeoRule{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeoRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeoRule) ProtoMessage() {}
+
+func (x *GeoRule) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeoRule.ProtoReflect.Descriptor instead.
+func (*GeoRule) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GeoRule) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *GeoRule) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// Variant is one weighted destination of an A/B split link
+type Variant struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Weight int32                  `protobuf:"varint,1,opt,name=weight,proto3" json:"weight,omitempty"`
+	Url    string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	// Assigned by the server ("A", "B", ...), ignored when creating links
+	Name          string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Variant) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Variant) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Variant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Campaign holds the UTM params added to the destination
+type Campaign struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Medium        string                 `protobuf:"bytes,2,opt,name=medium,proto3" json:"medium,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Term          string                 `protobuf:"bytes,4,opt,name=term,proto3" json:"term,omitempty"`
+	Content       string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Campaign) Reset() {
+	*x = Campaign{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Campaign) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Campaign) ProtoMessage() {}
+
+func (x *Campaign) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Campaign.ProtoReflect.Descriptor instead.
+func (*Campaign) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Campaign) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Campaign) GetMedium() string {
+	if x != nil {
+		return x.Medium
+	}
+	return ""
+}
+
+func (x *Campaign) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Campaign) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+func (x *Campaign) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type CreateLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Link          *LinkOptions           `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateLinkRequest) Reset() {
+	*x = CreateLinkRequest{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateLinkRequest) ProtoMessage() {}
+
+func (x *CreateLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateLinkRequest.ProtoReflect.Descriptor instead.
+func (*CreateLinkRequest) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateLinkRequest) GetLink() *LinkOptions {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+type CreateLinkResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Code     string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	ShortUrl string                 `protobuf:"bytes,2,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	// Set if an equivalent link already existed and was reused
+	Existing      bool `protobuf:"varint,3,opt,name=existing,proto3" json:"existing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateLinkResponse) Reset() {
+	*x = CreateLinkResponse{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateLinkResponse) ProtoMessage() {}
+
+func (x *CreateLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateLinkResponse.ProtoReflect.Descriptor instead.
+func (*CreateLinkResponse) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateLinkResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *CreateLinkResponse) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *CreateLinkResponse) GetExisting() bool {
+	if x != nil {
+		return x.Existing
+	}
+	return false
+}
+
+type ResolveLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveLinkRequest) Reset() {
+	*x = ResolveLinkRequest{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveLinkRequest) ProtoMessage() {}
+
+func (x *ResolveLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveLinkRequest.ProtoReflect.Descriptor instead.
+func (*ResolveLinkRequest) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ResolveLinkRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type ResolveLinkResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Empty for password-protected links
+	LongUrl   string `protobuf:"bytes,2,opt,name=long_url,json=longUrl,proto3" json:"long_url,omitempty"`
+	Protected bool   `protobuf:"varint,3,opt,name=protected,proto3" json:"protected,omitempty"`
+	Disabled  bool   `protobuf:"varint,4,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	// Unset for links created before creation dates were recorded
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Targeting rules and variants, empty for protected links
+	Rules         []*Rule    `protobuf:"bytes,6,rep,name=rules,proto3" json:"rules,omitempty"`
+	Variants      []*Variant `protobuf:"bytes,7,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveLinkResponse) Reset() {
+	*x = ResolveLinkResponse{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveLinkResponse) ProtoMessage() {}
+
+func (x *ResolveLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveLinkResponse.ProtoReflect.Descriptor instead.
+func (*ResolveLinkResponse) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ResolveLinkResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ResolveLinkResponse) GetLongUrl() string {
+	if x != nil {
+		return x.LongUrl
+	}
+	return ""
+}
+
+func (x *ResolveLinkResponse) GetProtected() bool {
+	if x != nil {
+		return x.Protected
+	}
+	return false
+}
+
+func (x *ResolveLinkResponse) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *ResolveLinkResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ResolveLinkResponse) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *ResolveLinkResponse) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+// Rule sends visitors matching kind ("country" or "platform") and value
+// to destination
+type Rule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Destination   string                 `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Rule) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Rule) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Rule) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetStatsRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type GetStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Clicks by people; bot clicks are counted apart
+	TotalClicks int64 `protobuf:"varint,1,opt,name=total_clicks,json=totalClicks,proto3" json:"total_clicks,omitempty"`
+	BotClicks   int64 `protobuf:"varint,5,opt,name=bot_clicks,json=botClicks,proto3" json:"bot_clicks,omitempty"`
+	// Unset if the link was never followed
+	LastVisitedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=last_visited_at,json=lastVisitedAt,proto3" json:"last_visited_at,omitempty"`
+	// Clicks per targeting rule, country and variant
+	Breakdown      []*BreakdownRow `protobuf:"bytes,3,rep,name=breakdown,proto3" json:"breakdown,omitempty"`
+	UniqueVisitors *UniqueVisitors `protobuf:"bytes,4,opt,name=unique_visitors,json=uniqueVisitors,proto3" json:"unique_visitors,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetStatsResponse) GetTotalClicks() int64 {
+	if x != nil {
+		return x.TotalClicks
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetBotClicks() int64 {
+	if x != nil {
+		return x.BotClicks
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetLastVisitedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastVisitedAt
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetBreakdown() []*BreakdownRow {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetUniqueVisitors() *UniqueVisitors {
+	if x != nil {
+		return x.UniqueVisitors
+	}
+	return nil
+}
+
+// Approximate counts of distinct visitors
+type UniqueVisitors struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Today int64                  `protobuf:"varint,1,opt,name=today,proto3" json:"today,omitempty"`
+	// Over the last 7 and 30 days
+	Week          int64 `protobuf:"varint,2,opt,name=week,proto3" json:"week,omitempty"`
+	Month         int64 `protobuf:"varint,3,opt,name=month,proto3" json:"month,omitempty"`
+	AllTime       int64 `protobuf:"varint,4,opt,name=all_time,json=allTime,proto3" json:"all_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UniqueVisitors) Reset() {
+	*x = UniqueVisitors{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UniqueVisitors) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UniqueVisitors) ProtoMessage() {}
+
+func (x *UniqueVisitors) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UniqueVisitors.ProtoReflect.Descriptor instead.
+func (*UniqueVisitors) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UniqueVisitors) GetToday() int64 {
+	if x != nil {
+		return x.Today
+	}
+	return 0
+}
+
+func (x *UniqueVisitors) GetWeek() int64 {
+	if x != nil {
+		return x.Week
+	}
+	return 0
+}
+
+func (x *UniqueVisitors) GetMonth() int64 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+func (x *UniqueVisitors) GetAllTime() int64 {
+	if x != nil {
+		return x.AllTime
+	}
+	return 0
+}
+
+type BreakdownRow struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "rule", "country" or "variant"
+	Dimension     string `protobuf:"bytes,1,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	Value         string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Clicks        int64  `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BreakdownRow) Reset() {
+	*x = BreakdownRow{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BreakdownRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakdownRow) ProtoMessage() {}
+
+func (x *BreakdownRow) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakdownRow.ProtoReflect.Descriptor instead.
+func (*BreakdownRow) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BreakdownRow) GetDimension() string {
+	if x != nil {
+		return x.Dimension
+	}
+	return ""
+}
+
+func (x *BreakdownRow) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *BreakdownRow) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+type BatchCreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Links         []*LinkOptions         `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateRequest) Reset() {
+	*x = BatchCreateRequest{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateRequest) ProtoMessage() {}
+
+func (x *BatchCreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateRequest.ProtoReflect.Descriptor instead.
+func (*BatchCreateRequest) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchCreateRequest) GetLinks() []*LinkOptions {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type BatchCreateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per requested link, in order
+	Results       []*BatchCreateResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateResponse) Reset() {
+	*x = BatchCreateResponse{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateResponse) ProtoMessage() {}
+
+func (x *BatchCreateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateResponse.ProtoReflect.Descriptor instead.
+func (*BatchCreateResponse) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchCreateResponse) GetResults() []*BatchCreateResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchCreateResult struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Index    int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Code     string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	ShortUrl string                 `protobuf:"bytes,3,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Existing bool                   `protobuf:"varint,4,opt,name=existing,proto3" json:"existing,omitempty"`
+	// Why the link was rejected; the other links are still created
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// Index of an earlier link of the same request this one repeats; both
+	// got the same new link
+	DuplicateOf   *int32 `protobuf:"varint,6,opt,name=duplicate_of,json=duplicateOf,proto3,oneof" json:"duplicate_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateResult) Reset() {
+	*x = BatchCreateResult{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateResult) ProtoMessage() {}
+
+func (x *BatchCreateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateResult.ProtoReflect.Descriptor instead.
+func (*BatchCreateResult) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BatchCreateResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BatchCreateResult) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *BatchCreateResult) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateResult) GetExisting() bool {
+	if x != nil {
+		return x.Existing
+	}
+	return false
+}
+
+func (x *BatchCreateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BatchCreateResult) GetDuplicateOf() int32 {
+	if x != nil && x.DuplicateOf != nil {
+		return *x.DuplicateOf
+	}
+	return 0
+}
+
+type StreamClicksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only stream clicks on this link, which must belong to the caller;
+	// all of the caller's links if empty
+	Code          string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamClicksRequest) Reset() {
+	*x = StreamClicksRequest{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamClicksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamClicksRequest) ProtoMessage() {}
+
+func (x *StreamClicksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamClicksRequest.ProtoReflect.Descriptor instead.
+func (*StreamClicksRequest) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamClicksRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type Click struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// Targeting rule, country and variant, if any
+	Rule          string `protobuf:"bytes,3,opt,name=rule,proto3" json:"rule,omitempty"`
+	Country       string `protobuf:"bytes,4,opt,name=country,proto3" json:"country,omitempty"`
+	Variant       string `protobuf:"bytes,5,opt,name=variant,proto3" json:"variant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Click) Reset() {
+	*x = Click{}
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Click) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Click) ProtoMessage() {}
+
+func (x *Click) ProtoReflect() protoreflect.Message {
+	mi := &file_miniurl_v1_shortener_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Click.ProtoReflect.Descriptor instead.
+func (*Click) Descriptor() ([]byte, []int) {
+	return file_miniurl_v1_shortener_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Click) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Click) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Click) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Click) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Click) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+var File_miniurl_v1_shortener_proto protoreflect.FileDescriptor
+
+const file_miniurl_v1_shortener_proto_rawDesc = "" +
+	"\n" +
+	"\x1aminiurl/v1/shortener.proto\x12\n" +
+	"miniurl.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x87\x05\n" +
+	"\vLinkOptions\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12#\n" +
+	"\rredirect_type\x18\x02 \x01(\x05R\fredirectType\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x03 \x01(\x03R\tmaxClicks\x129\n" +
+	"\n" +
+	"not_before\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tnotBefore\x127\n" +
+	"\tnot_after\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bnotAfter\x12!\n" +
+	"\ffallback_url\x18\x06 \x01(\tR\vfallbackUrl\x12>\n" +
+	"\atargets\x18\a \x03(\v2$.miniurl.v1.LinkOptions.TargetsEntryR\atargets\x120\n" +
+	"\tgeo_rules\x18\b \x03(\v2\x13.miniurl.v1.GeoRuleR\bgeoRules\x12/\n" +
+	"\bvariants\x18\t \x03(\v2\x13.miniurl.v1.VariantR\bvariants\x12\x1d\n" +
+	"\n" +
+	"pass_query\x18\n" +
+	" \x01(\bR\tpassQuery\x12\x1b\n" +
+	"\tpass_path\x18\v \x01(\bR\bpassPath\x12\x1a\n" +
+	"\bpassword\x18\f \x01(\tR\bpassword\x12\"\n" +
+	"\finterstitial\x18\r \x01(\tR\finterstitial\x120\n" +
+	"\bcampaign\x18\x0e \x01(\v2\x14.miniurl.v1.CampaignR\bcampaign\x1a:\n" +
+	"\fTargetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"5\n" +
+	"\aGeoRule\x12\x18\n" +
+	"\acountry\x18\x01 \x01(\tR\acountry\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"G\n" +
+	"\aVariant\x12\x16\n" +
+	"\x06weight\x18\x01 \x01(\x05R\x06weight\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"|\n" +
+	"\bCampaign\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06medium\x18\x02 \x01(\tR\x06medium\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04term\x18\x04 \x01(\tR\x04term\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\"@\n" +
+	"\x11CreateLinkRequest\x12+\n" +
+	"\x04link\x18\x01 \x01(\v2\x17.miniurl.v1.LinkOptionsR\x04link\"a\n" +
+	"\x12CreateLinkResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x1b\n" +
+	"\tshort_url\x18\x02 \x01(\tR\bshortUrl\x12\x1a\n" +
+	"\bexisting\x18\x03 \x01(\bR\bexisting\"(\n" +
+	"\x12ResolveLinkRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"\x92\x02\n" +
+	"\x13ResolveLinkResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x19\n" +
+	"\blong_url\x18\x02 \x01(\tR\alongUrl\x12\x1c\n" +
+	"\tprotected\x18\x03 \x01(\bR\tprotected\x12\x1a\n" +
+	"\bdisabled\x18\x04 \x01(\bR\bdisabled\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12&\n" +
+	"\x05rules\x18\x06 \x03(\v2\x10.miniurl.v1.RuleR\x05rules\x12/\n" +
+	"\bvariants\x18\a \x03(\v2\x13.miniurl.v1.VariantR\bvariants\"R\n" +
+	"\x04Rule\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12 \n" +
+	"\vdestination\x18\x03 \x01(\tR\vdestination\"%\n" +
+	"\x0fGetStatsRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"\x95\x02\n" +
+	"\x10GetStatsResponse\x12!\n" +
+	"\ftotal_clicks\x18\x01 \x01(\x03R\vtotalClicks\x12\x1d\n" +
+	"\n" +
+	"bot_clicks\x18\x05 \x01(\x03R\tbotClicks\x12B\n" +
+	"\x0flast_visited_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\rlastVisitedAt\x126\n" +
+	"\tbreakdown\x18\x03 \x03(\v2\x18.miniurl.v1.BreakdownRowR\tbreakdown\x12C\n" +
+	"\x0funique_visitors\x18\x04 \x01(\v2\x1a.miniurl.v1.UniqueVisitorsR\x0euniqueVisitors\"k\n" +
+	"\x0eUniqueVisitors\x12\x14\n" +
+	"\x05today\x18\x01 \x01(\x03R\x05today\x12\x12\n" +
+	"\x04week\x18\x02 \x01(\x03R\x04week\x12\x14\n" +
+	"\x05month\x18\x03 \x01(\x03R\x05month\x12\x19\n" +
+	"\ball_time\x18\x04 \x01(\x03R\aallTime\"Z\n" +
+	"\fBreakdownRow\x12\x1c\n" +
+	"\tdimension\x18\x01 \x01(\tR\tdimension\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06clicks\x18\x03 \x01(\x03R\x06clicks\"C\n" +
+	"\x12BatchCreateRequest\x12-\n" +
+	"\x05links\x18\x01 \x03(\v2\x17.miniurl.v1.LinkOptionsR\x05links\"N\n" +
+	"\x13BatchCreateResponse\x127\n" +
+	"\aresults\x18\x01 \x03(\v2\x1d.miniurl.v1.BatchCreateResultR\aresults\"\xc5\x01\n" +
+	"\x11BatchCreateResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x1b\n" +
+	"\tshort_url\x18\x03 \x01(\tR\bshortUrl\x12\x1a\n" +
+	"\bexisting\x18\x04 \x01(\bR\bexisting\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12&\n" +
+	"\fduplicate_of\x18\x06 \x01(\x05H\x00R\vduplicateOf\x88\x01\x01B\x0f\n" +
+	"\r_duplicate_of\")\n" +
+	"\x13StreamClicksRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"\x93\x01\n" +
+	"\x05Click\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04rule\x18\x03 \x01(\tR\x04rule\x12\x18\n" +
+	"\acountry\x18\x04 \x01(\tR\acountry\x12\x18\n" +
+	"\avariant\x18\x05 \x01(\tR\avariant2\x85\x03\n" +
+	"\tShortener\x12K\n" +
+	"\n" +
+	"CreateLink\x12\x1d.miniurl.v1.CreateLinkRequest\x1a\x1e.miniurl.v1.CreateLinkResponse\x12N\n" +
+	"\vResolveLink\x12\x1e.miniurl.v1.ResolveLinkRequest\x1a\x1f.miniurl.v1.ResolveLinkResponse\x12E\n" +
+	"\bGetStats\x12\x1b.miniurl.v1.GetStatsRequest\x1a\x1c.miniurl.v1.GetStatsResponse\x12N\n" +
+	"\vBatchCreate\x12\x1e.miniurl.v1.BatchCreateRequest\x1a\x1f.miniurl.v1.BatchCreateResponse\x12D\n" +
+	"\fStreamClicks\x12\x1f.miniurl.v1.StreamClicksRequest\x1a\x11.miniurl.v1.Click0\x01B*Z(url-shortener/proto/miniurl/v1;miniurlv1b\x06proto3"
+
+var (
+	file_miniurl_v1_shortener_proto_rawDescOnce sync.Once
+	file_miniurl_v1_shortener_proto_rawDescData []byte
+)
+
+func file_miniurl_v1_shortener_proto_rawDescGZIP() []byte {
+	file_miniurl_v1_shortener_proto_rawDescOnce.Do(func() {
+		file_miniurl_v1_shortener_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_miniurl_v1_shortener_proto_rawDesc), len(file_miniurl_v1_shortener_proto_rawDesc)))
+	})
+	return file_miniurl_v1_shortener_proto_rawDescData
+}
+
+var file_miniurl_v1_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_miniurl_v1_shortener_proto_goTypes = []any{
+	(*LinkOptions)(nil),           // 0: miniurl.v1.LinkOptions
+	(*GeoRule)(nil),               // 1: miniurl.v1.GeoRule
+	(*Variant)(nil),               // 2: miniurl.v1.Variant
+	(*Campaign)(nil),              // 3: miniurl.v1.Campaign
+	(*CreateLinkRequest)(nil),     // 4: miniurl.v1.CreateLinkRequest
+	(*CreateLinkResponse)(nil),    // 5: miniurl.v1.CreateLinkResponse
+	(*ResolveLinkRequest)(nil),    // 6: miniurl.v1.ResolveLinkRequest
+	(*ResolveLinkResponse)(nil),   // 7: miniurl.v1.ResolveLinkResponse
+	(*Rule)(nil),                  // 8: miniurl.v1.Rule
+	(*GetStatsRequest)(nil),       // 9: miniurl.v1.GetStatsRequest
+	(*GetStatsResponse)(nil),      // 10: miniurl.v1.GetStatsResponse
+	(*UniqueVisitors)(nil),        // 11: miniurl.v1.UniqueVisitors
+	(*BreakdownRow)(nil),          // 12: miniurl.v1.BreakdownRow
+	(*BatchCreateRequest)(nil),    // 13: miniurl.v1.BatchCreateRequest
+	(*BatchCreateResponse)(nil),   // 14: miniurl.v1.BatchCreateResponse
+	(*BatchCreateResult)(nil),     // 15: miniurl.v1.BatchCreateResult
+	(*StreamClicksRequest)(nil),   // 16: miniurl.v1.StreamClicksRequest
+	(*Click)(nil),                 // 17: miniurl.v1.Click
+	nil,                           // 18: miniurl.v1.LinkOptions.TargetsEntry
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+}
+var file_miniurl_v1_shortener_proto_depIdxs = []int32{
+	19, // 0: miniurl.v1.LinkOptions.not_before:type_name -> google.protobuf.Timestamp
+	19, // 1: miniurl.v1.LinkOptions.not_after:type_name -> google.protobuf.Timestamp
+	18, // 2: miniurl.v1.LinkOptions.targets:type_name -> miniurl.v1.LinkOptions.TargetsEntry
+	1,  // 3: miniurl.v1.LinkOptions.geo_rules:type_name -> miniurl.v1.GeoRule
+	2,  // 4: miniurl.v1.LinkOptions.variants:type_name -> miniurl.v1.Variant
+	3,  // 5: miniurl.v1.LinkOptions.campaign:type_name -> miniurl.v1.Campaign
+	0,  // 6: miniurl.v1.CreateLinkRequest.link:type_name -> miniurl.v1.LinkOptions
+	19, // 7: miniurl.v1.ResolveLinkResponse.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 8: miniurl.v1.ResolveLinkResponse.rules:type_name -> miniurl.v1.Rule
+	2,  // 9: miniurl.v1.ResolveLinkResponse.variants:type_name -> miniurl.v1.Variant
+	19, // 10: miniurl.v1.GetStatsResponse.last_visited_at:type_name -> google.protobuf.Timestamp
+	12, // 11: miniurl.v1.GetStatsResponse.breakdown:type_name -> miniurl.v1.BreakdownRow
+	11, // 12: miniurl.v1.GetStatsResponse.unique_visitors:type_name -> miniurl.v1.UniqueVisitors
+	0,  // 13: miniurl.v1.BatchCreateRequest.links:type_name -> miniurl.v1.LinkOptions
+	15, // 14: miniurl.v1.BatchCreateResponse.results:type_name -> miniurl.v1.BatchCreateResult
+	19, // 15: miniurl.v1.Click.time:type_name -> google.protobuf.Timestamp
+	4,  // 16: miniurl.v1.Shortener.CreateLink:input_type -> miniurl.v1.CreateLinkRequest
+	6,  // 17: miniurl.v1.Shortener.ResolveLink:input_type -> miniurl.v1.ResolveLinkRequest
+	9,  // 18: miniurl.v1.Shortener.GetStats:input_type -> miniurl.v1.GetStatsRequest
+	13, // 19: miniurl.v1.Shortener.BatchCreate:input_type -> miniurl.v1.BatchCreateRequest
+	16, // 20: miniurl.v1.Shortener.StreamClicks:input_type -> miniurl.v1.StreamClicksRequest
+	5,  // 21: miniurl.v1.Shortener.CreateLink:output_type -> miniurl.v1.CreateLinkResponse
+	7,  // 22: miniurl.v1.Shortener.ResolveLink:output_type -> miniurl.v1.ResolveLinkResponse
+	10, // 23: miniurl.v1.Shortener.GetStats:output_type -> miniurl.v1.GetStatsResponse
+	14, // 24: miniurl.v1.Shortener.BatchCreate:output_type -> miniurl.v1.BatchCreateResponse
+	17, // 25: miniurl.v1.Shortener.StreamClicks:output_type -> miniurl.v1.Click
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_miniurl_v1_shortener_proto_init() }
+func file_miniurl_v1_shortener_proto_init() {
+	if File_miniurl_v1_shortener_proto != nil {
+		return
+	}
+	file_miniurl_v1_shortener_proto_msgTypes[15].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_miniurl_v1_shortener_proto_rawDesc), len(file_miniurl_v1_shortener_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_miniurl_v1_shortener_proto_goTypes,
+		DependencyIndexes: file_miniurl_v1_shortener_proto_depIdxs,
+		MessageInfos:      file_miniurl_v1_shortener_proto_msgTypes,
+	}.Build()
+	File_miniurl_v1_shortener_proto = out.File
+	file_miniurl_v1_shortener_proto_goTypes = nil
+	file_miniurl_v1_shortener_proto_depIdxs = nil
+}
@@ -9,6 +9,11 @@ document.body.addEventListener("htmx:responseError", e => {
     setTimeout(() => toast.classList.add("hidden"), 4500);
 });
 
+// Send the browser's timezone so datetime-local inputs are read correctly
+document.body.addEventListener("htmx:configRequest", e => {
+    e.detail.parameters.tz_offset = new Date().getTimezoneOffset();
+});
+
 // Sidebar active state
 window.setActive = btn => {
     const activeStateClasses = ["bg-blue-50", "text-blue-700"]
@@ -52,4 +57,46 @@ const refreshUI = () => {
 };
 ["DOMContentLoaded", "load", "htmx:afterSwap"].forEach(evt =>
     document.addEventListener(evt, refreshUI)
-);
\ No newline at end of file
+);
+// Live click stats: follow the link's event stream while its stats are shown
+let clickStream = null;
+const formatTime = ts => {
+    const d = new Date(ts);
+    return isNaN(d) ? "—" : d.toLocaleString(undefined, { dateStyle: "medium", timeStyle: "medium" });
+};
+const followClicks = () => {
+    const panel = document.getElementById("click-stats");
+    if (clickStream && clickStream.panel === panel) return;
+    clickStream?.close();
+    clickStream = null;
+    if (!panel?.dataset.stream) return;
+
+    clickStream = new EventSource(panel.dataset.stream);
+    clickStream.panel = panel;
+    const status = document.getElementById("live-status");
+    clickStream.onopen = () => status.textContent = "live";
+    clickStream.onerror = () => status.textContent = "reconnecting…";
+
+    clickStream.addEventListener("stats", e => {
+        const stats = JSON.parse(e.data);
+        document.getElementById("total-clicks").textContent = stats.total_clicks;
+    });
+    clickStream.addEventListener("click", e => {
+        const click = JSON.parse(e.data);
+        const total = document.getElementById("total-clicks");
+        total.textContent = Number(total.textContent) + 1;
+        document.getElementById("last-visited").textContent = formatTime(click.time);
+        if (click.variant) {
+            const variant = panel.querySelector(`[data-variant="${CSS.escape(click.variant)}"]`);
+            if (variant) variant.textContent = Number(variant.textContent) + 1;
+        }
+
+        const item = document.createElement("li");
+        item.textContent = [formatTime(click.time), click.country, click.variant && `variant ${click.variant}`]
+            .filter(Boolean).join(" · ");
+        const list = document.getElementById("recent-visits");
+        list.prepend(item);
+        while (list.children.length > 10) list.lastElementChild.remove();
+    });
+};
+document.addEventListener("htmx:afterSwap", followClicks);
@@ -0,0 +1,8 @@
+// Package static embeds the web UI's pages, fragments and assets,
+// so the server doesn't depend on its working directory
+package static
+
+import "embed"
+
+//go:embed *.html *.js *.png pages partials
+var Files embed.FS